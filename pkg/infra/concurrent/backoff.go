@@ -0,0 +1,66 @@
+package concurrent
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff yields growing retry delays: Base, then Base*Multiplier, and so
+// on, capped at Max. It is not safe for concurrent use; give each retry
+// loop its own Backoff.
+type Backoff struct {
+	// Base is the first delay
+	Base time.Duration
+	// Multiplier scales each delay from the previous one; values below 1
+	// keep the delay constant
+	Multiplier float64
+	// Max caps every delay; zero means no cap
+	Max time.Duration
+	// Jitter randomizes each delay by up to this fraction of it in either
+	// direction, e.g. 0.2 for ±20%; zero disables jitter
+	Jitter float64
+	// Rand returns values in [0, 1) for jitter; nil uses math/rand
+	Rand func() float64
+
+	attempt int
+}
+
+// NewBackoff creates a Backoff without jitter
+func NewBackoff(base time.Duration, multiplier float64, max time.Duration) *Backoff {
+	return &Backoff{Base: base, Multiplier: multiplier, Max: max}
+}
+
+// Next returns the delay before the next attempt
+func (b *Backoff) Next() time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.Base) * math.Pow(multiplier, float64(b.attempt))
+	b.attempt++
+
+	if b.Jitter > 0 {
+		random := rand.Float64
+		if b.Rand != nil {
+			random = b.Rand
+		}
+		delay *= 1 + b.Jitter*(2*random()-1)
+	}
+
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// Reset restarts the sequence from Base
+func (b *Backoff) Reset() {
+	b.attempt = 0
+}
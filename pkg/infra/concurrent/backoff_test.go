@@ -0,0 +1,65 @@
+package concurrent
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// delays returns the next n delays of b
+func delays(b *Backoff, n int) []time.Duration {
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = b.Next()
+	}
+	return out
+}
+
+func TestBackoffGrowsCapsAndResets(t *testing.T) {
+	b := NewBackoff(100*time.Millisecond, 2, time.Second)
+
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}, delays(b, 6))
+
+	b.Reset()
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, delays(b, 2))
+}
+
+func TestBackoffWithFixedJitter(t *testing.T) {
+	// 0.75 scales each delay by 1 + 0.2*(2*0.75-1) = 1.1
+	b := &Backoff{Base: 100 * time.Millisecond, Multiplier: 2, Max: 500 * time.Millisecond, Jitter: 0.2, Rand: func() float64 { return 0.75 }}
+
+	assert.Equal(t, []time.Duration{
+		110 * time.Millisecond,
+		220 * time.Millisecond,
+		440 * time.Millisecond,
+		500 * time.Millisecond,
+	}, delays(b, 4), "jitter is applied before the cap")
+
+	b.Rand = func() float64 { return 0 }
+	b.Reset()
+	assert.Equal(t, 80*time.Millisecond, b.Next(), "the lowest jitter shrinks by the full fraction")
+}
+
+func TestBackoffConstantBelowMultiplierOne(t *testing.T) {
+	b := NewBackoff(50*time.Millisecond, 0.5, 0)
+
+	assert.Equal(t, []time.Duration{50 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}, delays(b, 3))
+}
+
+func TestBackoffUncappedSaturates(t *testing.T) {
+	b := NewBackoff(time.Hour, 10, 0)
+	for i := 0; i < 30; i++ {
+		b.Next()
+	}
+
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Next())
+}
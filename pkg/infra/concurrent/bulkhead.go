@@ -0,0 +1,64 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBulkheadFull is returned when a rejecting bulkhead has no free slot
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// BulkheadMode controls what happens when a bulkhead is at capacity
+type BulkheadMode int
+
+const (
+	// BulkheadQueue waits for a free slot until the context ends
+	BulkheadQueue BulkheadMode = iota
+	// BulkheadReject fails immediately with ErrBulkheadFull
+	BulkheadReject
+)
+
+// Bulkhead limits concurrent calls to a downstream so a slow dependency
+// can't exhaust every goroutine
+type Bulkhead struct {
+	slots chan struct{}
+	mode  BulkheadMode
+}
+
+// NewBulkhead creates a bulkhead allowing at most max concurrent executions
+func NewBulkhead(max int, mode BulkheadMode) *Bulkhead {
+	return &Bulkhead{
+		slots: make(chan struct{}, max),
+		mode:  mode,
+	}
+}
+
+// Run executes fn once a slot is available
+func (b *Bulkhead) Run(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch b.mode {
+	case BulkheadReject:
+		select {
+		case b.slots <- struct{}{}:
+		default:
+			return ErrBulkheadFull
+		}
+	default:
+		select {
+		case b.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer func() { <-b.slots }()
+
+	return fn()
+}
+
+// InFlight returns the number of executions currently running
+func (b *Bulkhead) InFlight() int {
+	return len(b.slots)
+}
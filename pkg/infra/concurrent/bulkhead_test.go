@@ -0,0 +1,77 @@
+package concurrent
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkheadCapsConcurrency(t *testing.T) {
+	b := NewBulkhead(3, BulkheadQueue)
+	var running, peak int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.Run(context.Background(), func() error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(3))
+	assert.Zero(t, b.InFlight())
+}
+
+func TestBulkheadRejectReturnsPromptlyWhenFull(t *testing.T) {
+	b := NewBulkhead(1, BulkheadReject)
+	started, release := make(chan struct{}), make(chan struct{})
+	go b.Run(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	receive(t, started)
+	defer close(release)
+
+	start := time.Now()
+	err := b.Run(context.Background(), func() error { return nil })
+
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestBulkheadQueueGivesUpWithContext(t *testing.T) {
+	b := NewBulkhead(1, BulkheadQueue)
+	started, release := make(chan struct{}), make(chan struct{})
+	go b.Run(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	receive(t, started)
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := b.Run(ctx, func() error { return nil })
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
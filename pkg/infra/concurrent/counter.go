@@ -6,7 +6,8 @@ import (
 
 // Counter represents an atomic counter
 type Counter struct {
-	value int64
+	value   int64
+	history *counterHistory
 }
 
 // NewCounter creates a new Counter
@@ -16,19 +17,62 @@ func NewCounter(initial int64) *Counter {
 
 // Increment atomically increments the counter by 1
 func (c *Counter) Increment() int64 {
+	if c.history != nil {
+		c.history.observe(c)
+	}
 	return atomic.AddInt64(&c.value, 1)
 }
 
 // Decrement atomically decrements the counter by 1
 func (c *Counter) Decrement() int64 {
+	if c.history != nil {
+		c.history.observe(c)
+	}
 	return atomic.AddInt64(&c.value, -1)
 }
 
 // Add atomically adds delta to the counter
 func (c *Counter) Add(delta int64) int64 {
+	if c.history != nil {
+		c.history.observe(c)
+	}
 	return atomic.AddInt64(&c.value, delta)
 }
 
+// AddClamped atomically adds delta to the counter, keeping the result
+// within [min, max], and returns the resulting value
+func (c *Counter) AddClamped(delta, min, max int64) int64 {
+	if c.history != nil {
+		c.history.observe(c)
+	}
+	for {
+		old := atomic.LoadInt64(&c.value)
+		next := clamp(old, delta, min, max)
+		if atomic.CompareAndSwapInt64(&c.value, old, next) {
+			return next
+		}
+	}
+}
+
+// clamp returns v+delta bounded to [min, max] without overflowing
+func clamp(v, delta, min, max int64) int64 {
+	switch {
+	case delta > 0 && v > max-delta:
+		v = max
+	case delta < 0 && v < min-delta:
+		v = min
+	default:
+		v += delta
+	}
+	if v > max {
+		return max
+	}
+	if v < min {
+		return min
+	}
+	return v
+}
+
 // Value returns the current value of the counter
 func (c *Counter) Value() int64 {
 	return atomic.LoadInt64(&c.value)
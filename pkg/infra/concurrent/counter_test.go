@@ -0,0 +1,51 @@
+package concurrent
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddClampedStaysWithinBoundsUnderConcurrency(t *testing.T) {
+	const min, max = 0, 10
+	c := NewCounter(5)
+	var escaped int64
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		delta := int64(3)
+		if g%2 == 1 {
+			delta = -3
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if v := c.AddClamped(delta, min, max); v < min || v > max {
+					atomic.AddInt64(&escaped, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Zero(t, atomic.LoadInt64(&escaped))
+	assert.GreaterOrEqual(t, c.Value(), int64(min))
+	assert.LessOrEqual(t, c.Value(), int64(max))
+}
+
+func TestAddClampedPinsAtBounds(t *testing.T) {
+	c := NewCounter(0)
+
+	assert.Equal(t, int64(0), c.AddClamped(-1, 0, 3), "a double decrement stays at the floor")
+	assert.Equal(t, int64(3), c.AddClamped(5, 0, 3))
+	assert.Equal(t, int64(2), c.AddClamped(-1, 0, 3))
+}
+
+func TestClampDoesNotOverflow(t *testing.T) {
+	assert.Equal(t, int64(math.MaxInt64), clamp(math.MaxInt64-1, 10, math.MinInt64, math.MaxInt64))
+	assert.Equal(t, int64(math.MinInt64), clamp(math.MinInt64+1, -10, math.MinInt64, math.MaxInt64))
+}
@@ -0,0 +1,111 @@
+package concurrent
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// eventBufferSize is the number of events buffered for a slow subscriber
+// before further events are dropped
+const eventBufferSize = 256
+
+// PoolEventType identifies a pool lifecycle event
+type PoolEventType int
+
+const (
+	// EventTaskStarted is emitted when a task takes a worker
+	EventTaskStarted PoolEventType = iota
+	// EventTaskFinished is emitted when a task returns nil
+	EventTaskFinished
+	// EventTaskFailed is emitted when a task returns an error or panics
+	EventTaskFailed
+	// EventQueueFull is emitted when a submission is rejected with
+	// ErrQueueFull
+	EventQueueFull
+)
+
+// String returns the string representation of the event type
+func (t PoolEventType) String() string {
+	switch t {
+	case EventTaskStarted:
+		return "task_started"
+	case EventTaskFinished:
+		return "task_finished"
+	case EventTaskFailed:
+		return "task_failed"
+	case EventQueueFull:
+		return "queue_full"
+	default:
+		return "unknown"
+	}
+}
+
+// PoolEvent describes a pool lifecycle event
+type PoolEvent struct {
+	Type PoolEventType
+	Time time.Time
+	// Duration is the run time of a finished or failed task
+	Duration time.Duration
+	// Err is the error or panic of a failed task
+	Err error
+}
+
+// Events returns a channel of pool lifecycle events. Every call returns the
+// same channel. Events are buffered, and dropped rather than blocking
+// workers once the buffer is full; DroppedEvents counts them. The channel
+// is closed when the pool is closed.
+func (p *Pool) Events() <-chan PoolEvent {
+	p.eventsMu.Lock()
+	defer p.eventsMu.Unlock()
+
+	if p.events == nil {
+		p.events = make(chan PoolEvent, eventBufferSize)
+		if p.eventsClosed {
+			close(p.events)
+		}
+	}
+	return p.events
+}
+
+// DroppedEvents returns the number of events dropped because the Events
+// buffer was full
+func (p *Pool) DroppedEvents() int64 {
+	return atomic.LoadInt64(&p.droppedEvents)
+}
+
+// subscribed reports whether Events has been called on an open pool
+func (p *Pool) subscribed() bool {
+	p.eventsMu.RLock()
+	defer p.eventsMu.RUnlock()
+	return p.events != nil && !p.eventsClosed
+}
+
+// emit sends event to the Events channel without blocking, counting it as
+// dropped if the buffer is full
+func (p *Pool) emit(event PoolEvent) {
+	p.eventsMu.RLock()
+	defer p.eventsMu.RUnlock()
+
+	if p.events == nil || p.eventsClosed {
+		return
+	}
+	select {
+	case p.events <- event:
+	default:
+		atomic.AddInt64(&p.droppedEvents, 1)
+	}
+}
+
+// closeEvents closes the Events channel once the pool has drained
+func (p *Pool) closeEvents() {
+	p.eventsMu.Lock()
+	defer p.eventsMu.Unlock()
+
+	if p.eventsClosed {
+		return
+	}
+	p.eventsClosed = true
+	if p.events != nil {
+		close(p.events)
+	}
+}
@@ -0,0 +1,103 @@
+package concurrent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain returns every event sent on ch until it is closed
+func drain(t *testing.T, ch <-chan PoolEvent) []PoolEvent {
+	t.Helper()
+	var events []PoolEvent
+	for {
+		e, ok := receive(t, ch)
+		if !ok {
+			return events
+		}
+		events = append(events, e)
+	}
+}
+
+// countTypes counts events by type
+func countTypes(events []PoolEvent) map[PoolEventType]int {
+	counts := make(map[PoolEventType]int)
+	for _, e := range events {
+		counts[e.Type]++
+	}
+	return counts
+}
+
+func TestEventsReportStartAndFinish(t *testing.T) {
+	p := NewPool(1)
+	events := p.Events()
+	declined := errors.New("declined")
+
+	require.NoError(t, p.Submit(func() error { return nil }))
+	require.NoError(t, p.Submit(func() error { return declined }))
+	p.Close()
+
+	got := drain(t, events)
+	assert.Equal(t, map[PoolEventType]int{
+		EventTaskStarted:  2,
+		EventTaskFinished: 1,
+		EventTaskFailed:   1,
+	}, countTypes(got))
+	for _, e := range got {
+		assert.False(t, e.Time.IsZero(), e.Type.String())
+		if e.Type == EventTaskFailed {
+			assert.ErrorIs(t, e.Err, declined)
+		}
+	}
+}
+
+func TestEventsReportPanicAsFailure(t *testing.T) {
+	p := NewPool(1, WithPanicHandler(func(interface{}) {}))
+	events := p.Events()
+
+	require.NoError(t, p.Submit(func() error { panic("boom") }))
+	p.Close()
+
+	got := drain(t, events)
+	require.Len(t, got, 2)
+	assert.Equal(t, EventTaskStarted, got[0].Type)
+	assert.Equal(t, EventTaskFailed, got[1].Type)
+	assert.EqualError(t, got[1].Err, "panic: boom")
+}
+
+func TestEventsReportQueueFull(t *testing.T) {
+	p := NewPool(1, WithQueueSize(1))
+	release := occupy(t, p)
+	events := p.Events()
+
+	require.NoError(t, p.Submit(func() error { return nil }))
+	assert.ErrorIs(t, p.Submit(func() error { return nil }), ErrQueueFull)
+	release()
+	p.Close()
+
+	assert.Equal(t, 1, countTypes(drain(t, events))[EventQueueFull])
+}
+
+func TestEventsDroppedWhenSubscriberIsSlow(t *testing.T) {
+	p := NewPool(4)
+	events := p.Events()
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, p.Submit(func() error { return nil }))
+	}
+	p.Close()
+
+	got := drain(t, events)
+	assert.Len(t, got, eventBufferSize)
+	assert.EqualValues(t, 400-eventBufferSize, p.DroppedEvents())
+}
+
+func TestEventsChannelClosedForClosedPool(t *testing.T) {
+	p := NewPool(1)
+	p.Close()
+
+	_, ok := receive(t, p.Events())
+	assert.False(t, ok)
+}
@@ -0,0 +1,45 @@
+package concurrent
+
+import "sync"
+
+// CounterGroup holds a lazily created Counter per key
+type CounterGroup struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+// NewCounterGroup creates an empty CounterGroup
+func NewCounterGroup() *CounterGroup {
+	return &CounterGroup{counters: make(map[string]*Counter)}
+}
+
+// Get returns the counter for key, creating it at zero on first use
+func (g *CounterGroup) Get(key string) *Counter {
+	g.mu.RLock()
+	c, ok := g.counters[key]
+	g.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.counters[key]; ok {
+		return c
+	}
+	c = NewCounter(0)
+	g.counters[key] = c
+	return c
+}
+
+// Snapshot returns the current value of every counter by key
+func (g *CounterGroup) Snapshot() map[string]int64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshot := make(map[string]int64, len(g.counters))
+	for key, c := range g.counters {
+		snapshot[key] = c.Value()
+	}
+	return snapshot
+}
@@ -0,0 +1,53 @@
+package concurrent
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterGroupConcurrentTotals(t *testing.T) {
+	g := NewCounterGroup()
+	const keys, workers, perWorker = 20, 8, 500
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				g.Get(fmt.Sprintf("status_%d", (w+i)%keys)).Increment()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	snapshot := g.Snapshot()
+	assert.Len(t, snapshot, keys)
+	var total int64
+	for key, v := range snapshot {
+		assert.Equal(t, int64(workers*perWorker/keys), v, key)
+		total += v
+	}
+	assert.Equal(t, int64(workers*perWorker), total)
+}
+
+func TestCounterGroupGetReturnsSameCounter(t *testing.T) {
+	g := NewCounterGroup()
+
+	assert.Same(t, g.Get("200"), g.Get("200"))
+	assert.NotSame(t, g.Get("200"), g.Get("500"))
+	assert.Equal(t, map[string]int64{"200": 0, "500": 0}, g.Snapshot())
+}
+
+func TestCounterGroupSnapshotIsACopy(t *testing.T) {
+	g := NewCounterGroup()
+	g.Get("200").Add(3)
+
+	snapshot := g.Snapshot()
+	g.Get("200").Add(1)
+
+	assert.Equal(t, int64(3), snapshot["200"])
+}
@@ -0,0 +1,116 @@
+package concurrent
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultHistoryInterval replaces a non-positive history interval
+const defaultHistoryInterval = time.Second
+
+// counterHistory keeps a ring buffer of a counter's per-interval deltas
+type counterHistory struct {
+	mu       sync.Mutex
+	interval time.Duration
+	now      func() time.Time
+	boundary int64 // UnixNano end of the current interval, read atomically
+	base     int64 // counter value at the start of the current interval
+	deltas   []int64
+	next     int
+	filled   int
+}
+
+// NewCounterWithHistory creates a Counter that also records how much it
+// changed in each of the last size intervals, for RecentRates. A
+// non-positive interval defaults to one second and size to one.
+func NewCounterWithHistory(initial int64, interval time.Duration, size int) *Counter {
+	c := NewCounter(initial)
+	c.history = newCounterHistory(initial, interval, size, time.Now)
+	return c
+}
+
+// newCounterHistory creates a history starting at the current time
+func newCounterHistory(initial int64, interval time.Duration, size int, now func() time.Time) *counterHistory {
+	if size <= 0 {
+		size = 1
+	}
+	if interval <= 0 {
+		interval = defaultHistoryInterval
+	}
+	h := &counterHistory{
+		interval: interval,
+		now:      now,
+		base:     initial,
+		deltas:   make([]int64, size),
+	}
+	h.boundary = now().Add(interval).UnixNano()
+	return h
+}
+
+// RecentRates returns the counter's deltas over the last n completed
+// intervals, oldest first. It returns nil if history isn't enabled or n
+// isn't positive.
+func (c *Counter) RecentRates(n int) []int64 {
+	h := c.history
+	if h == nil || n <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roll(c.Value())
+
+	if n > h.filled {
+		n = h.filled
+	}
+	rates := make([]int64, n)
+	for i := 0; i < n; i++ {
+		idx := (h.next - n + i + len(h.deltas)) % len(h.deltas)
+		rates[i] = h.deltas[idx]
+	}
+	return rates
+}
+
+// observe closes any intervals that ended before a write. The common case
+// is a single atomic load and clock read.
+func (h *counterHistory) observe(c *Counter) {
+	if h.now().UnixNano() < atomic.LoadInt64(&h.boundary) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.roll(c.Value())
+}
+
+// roll closes every interval that has ended, attributing the change since
+// the last close to the first of them. Callers must hold h.mu.
+func (h *counterHistory) roll(value int64) {
+	now := h.now().UnixNano()
+	boundary := atomic.LoadInt64(&h.boundary)
+	if now < boundary {
+		return
+	}
+
+	elapsed := (now-boundary)/int64(h.interval) + 1
+	for i := int64(0); i < elapsed && i < int64(len(h.deltas)); i++ {
+		delta := int64(0)
+		if i == 0 {
+			delta = value - h.base
+		}
+		h.push(delta)
+	}
+
+	h.base = value
+	atomic.StoreInt64(&h.boundary, boundary+elapsed*int64(h.interval))
+}
+
+// push appends a delta to the ring buffer
+func (h *counterHistory) push(delta int64) {
+	h.deltas[h.next] = delta
+	h.next = (h.next + 1) % len(h.deltas)
+	if h.filled < len(h.deltas) {
+		h.filled++
+	}
+}
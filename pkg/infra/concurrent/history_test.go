@@ -0,0 +1,77 @@
+package concurrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// counterWithFakeClock returns a counter keeping size one-second intervals
+// of history, timed by the returned clock
+func counterWithFakeClock(size int) (*Counter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	c := NewCounter(0)
+	c.history = newCounterHistory(0, time.Second, size, clock.now)
+	return c, clock
+}
+
+func TestRecentRatesRecordsPerIntervalDeltas(t *testing.T) {
+	c, clock := counterWithFakeClock(3)
+	assert.Empty(t, c.RecentRates(3), "no interval has completed")
+
+	c.Add(5)
+	clock.advance(time.Second)
+	c.Add(2)
+	assert.Equal(t, []int64{5}, c.RecentRates(3))
+
+	clock.advance(time.Second)
+	assert.Equal(t, []int64{5, 2}, c.RecentRates(3))
+
+	c.Increment()
+	clock.advance(3 * time.Second)
+	assert.Equal(t, []int64{1, 0, 0}, c.RecentRates(3), "idle intervals record zero and the ring wraps")
+	assert.Equal(t, []int64{0, 0}, c.RecentRates(2), "the last n, oldest first")
+}
+
+func TestRecentRatesCountsDecrements(t *testing.T) {
+	c, clock := counterWithFakeClock(2)
+
+	c.Add(4)
+	c.Decrement()
+	clock.advance(time.Second)
+	c.Add(-3)
+	clock.advance(time.Second)
+
+	assert.Equal(t, []int64{3, -3}, c.RecentRates(2))
+}
+
+func TestRecentRatesWithoutHistory(t *testing.T) {
+	c := NewCounter(0)
+	c.Add(1)
+	assert.Nil(t, c.RecentRates(5))
+}
+
+func TestNewCounterWithHistoryDefaults(t *testing.T) {
+	c := NewCounterWithHistory(0, 0, 0)
+	assert.Equal(t, defaultHistoryInterval, c.history.interval)
+	assert.Len(t, c.history.deltas, 1)
+}
+
+func TestRecentRatesNonPositiveN(t *testing.T) {
+	c, clock := counterWithFakeClock(3)
+	c.Add(2)
+	clock.advance(time.Second)
+
+	assert.Nil(t, c.RecentRates(0))
+	assert.Nil(t, c.RecentRates(-1))
+}
@@ -0,0 +1,87 @@
+package concurrent
+
+import (
+	"order-system/pkg/platform/logger"
+	"order-system/pkg/platform/metrics"
+)
+
+// Pool task metric names
+const (
+	poolTasksMetric        = "pool_tasks_total"
+	poolTaskDurationMetric = "pool_task_duration_seconds"
+)
+
+// Option configures a Pool
+type Option func(*Pool)
+
+// WithQueueSize bounds the number of tasks waiting for a worker; Submit
+// returns ErrQueueFull beyond it. Zero leaves the queue unbounded.
+func WithQueueSize(n int) Option {
+	return func(p *Pool) {
+		p.queueSize = n
+	}
+}
+
+// WithPanicHandler recovers task panics and passes the recovered value to
+// fn instead of crashing the process
+func WithPanicHandler(fn func(r interface{})) Option {
+	return func(p *Pool) {
+		p.panicHandler = fn
+	}
+}
+
+// WithLogger logs task lifecycle events, panics and shutdown through log
+func WithLogger(log logger.Logger) Option {
+	return func(p *Pool) {
+		p.log = log.WithComponent("pool")
+	}
+}
+
+// WithMetrics records the pool_tasks_total counter, labelled with a result
+// of success, failure or panic, and the pool_task_duration_seconds
+// histogram on c. Registration errors are ignored so several pools can
+// share c.
+func WithMetrics(c metrics.Collector) Option {
+	return func(p *Pool) {
+		_ = c.Register(poolTasksMetric, metrics.Counter, "Tasks run by the pool by result")
+		_ = c.Register(poolTaskDurationMetric, metrics.Histogram, "Task run time in seconds")
+		p.metrics = c
+	}
+}
+
+// WithWorkStealing gives each worker its own queue; submitted tasks are
+// spread across the queues and idle workers take tasks from busy ones
+func WithWorkStealing() Option {
+	return func(p *Pool) {
+		p.workStealing = true
+	}
+}
+
+// WithFairness starts queued tasks in the order they were submitted.
+// Without it any waiting task may take a freed worker. It has no effect
+// together with WithWorkStealing.
+func WithFairness() Option {
+	return func(p *Pool) {
+		p.fair = true
+	}
+}
+
+// WorkStealing enables or disables work stealing.
+//
+// Deprecated: use WithWorkStealing.
+func WorkStealing(enabled bool) Option {
+	if !enabled {
+		return func(p *Pool) { p.workStealing = false }
+	}
+	return WithWorkStealing()
+}
+
+// Fair enables or disables starting queued tasks in submission order.
+//
+// Deprecated: use WithFairness.
+func Fair(enabled bool) Option {
+	if !enabled {
+		return func(p *Pool) { p.fair = false }
+	}
+	return WithFairness()
+}
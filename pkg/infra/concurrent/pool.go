@@ -1,13 +1,34 @@
 package concurrent
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/platform/logger"
+	"order-system/pkg/platform/metrics"
 )
 
+// ErrPoolClosed is returned when submitting to a closed pool
+var ErrPoolClosed = errors.New("pool is closed")
+
+// ErrQueueFull is returned when submitting to a pool whose queue is full
+var ErrQueueFull = errors.New("pool queue is full")
+
 // Task represents a function that can be executed by the pool
 type Task func() error
 
+// Result represents the outcome of a streamed task
+type Result struct {
+	Value interface{}
+	Err   error
+	Index int
+}
+
 // Pool represents a worker pool
 type Pool struct {
 	workers    chan struct{}
@@ -15,44 +36,433 @@ type Pool struct {
 	activeJobs int32
 	closed     bool
 	mu         sync.Mutex
+	log        logger.Logger
+	queued     int64
+	queueSize  int
+
+	// panicHandler receives the value of a recovered task panic
+	panicHandler func(r interface{})
+	// metrics records task outcomes and durations when set
+	metrics metrics.Collector
+
+	// keys holds the tasks waiting behind the running task of each key
+	// submitted through SubmitKeyed; a key is present while one is running
+	keyMu sync.Mutex
+	keys  map[string][]Task
+
+	// workStealing runs tasks on per-worker queues through stealer
+	// instead of one goroutine per task
+	workStealing bool
+	stealer      *stealer
+
+	// events receives lifecycle events once Events has been called
+	eventsMu      sync.RWMutex
+	events        chan PoolEvent
+	eventsClosed  bool
+	droppedEvents int64
+
+	// fair starts queued tasks in submission order from fifo
+	fair   bool
+	fifoMu sync.Mutex
+	fifo   []func() error
 }
 
 // NewPool creates a new worker pool with the specified number of workers
-func NewPool(size int) *Pool {
-	return &Pool{
+// configured by opts
+func NewPool(size int, opts ...Option) *Pool {
+	p := &Pool{
 		workers: make(chan struct{}, size),
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.workStealing {
+		p.stealer = newStealer(p, size)
+	}
+	return p
+}
+
+// NewPoolWithLogger creates a new worker pool that logs task lifecycle
+// events, panics and shutdown through log.
+//
+// Deprecated: use NewPool(size, WithLogger(log)).
+func NewPoolWithLogger(size int, log logger.Logger) *Pool {
+	return NewPool(size, WithLogger(log))
 }
 
-// Submit submits a task to the pool
+// NewPoolWithOptions creates a new worker pool with size workers
+// configured by opts.
+//
+// Deprecated: use NewPool, which takes the same options.
+func NewPoolWithOptions(size int, opts ...Option) *Pool {
+	return NewPool(size, opts...)
+}
+
+// Submit submits a task to the pool. It returns ErrQueueFull when the
+// pool has a queue size and that many tasks are already waiting.
 func (p *Pool) Submit(task func() error) error {
 	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.closed {
-		p.mu.Unlock()
-		return errors.New("pool is closed")
+		return ErrPoolClosed
 	}
-	p.mu.Unlock()
+	if p.queueSize > 0 && p.QueueDepth() >= p.queueSize {
+		p.emit(PoolEvent{Type: EventQueueFull, Time: time.Now()})
+		return ErrQueueFull
+	}
+
+	p.enqueue(task)
+	return nil
+}
 
+// enqueue schedules task to run once a worker is free
+func (p *Pool) enqueue(task func() error) {
 	p.wg.Add(1)
+	atomic.AddInt64(&p.queued, 1)
+	if p.stealer != nil {
+		p.stealer.push(task)
+		return
+	}
+	if p.fair {
+		p.fifoMu.Lock()
+		p.fifo = append(p.fifo, task)
+		p.fifoMu.Unlock()
+		p.dispatch()
+		return
+	}
 	go func() {
 		defer p.wg.Done()
-		p.workers <- struct{}{}        // acquire worker
+		p.workers <- struct{}{} // acquire worker
+		atomic.AddInt64(&p.queued, -1)
 		defer func() { <-p.workers }() // release worker
-		_ = task()
+		p.run(task)
 	}()
+}
+
+// dispatch starts queued tasks in submission order while workers are free
+func (p *Pool) dispatch() {
+	p.fifoMu.Lock()
+	defer p.fifoMu.Unlock()
+
+	for len(p.fifo) > 0 {
+		select {
+		case p.workers <- struct{}{}: // acquire worker
+		default:
+			return
+		}
+
+		task := p.fifo[0]
+		p.fifo[0] = nil
+		p.fifo = p.fifo[1:]
+		atomic.AddInt64(&p.queued, -1)
 
+		go func() {
+			p.run(task)
+			<-p.workers // release worker
+			p.wg.Done()
+			p.dispatch()
+		}()
+	}
+}
+
+// SubmitKeyed submits a task that never runs concurrently with another
+// task of the same key. Tasks of one key run in submission order, and
+// each occupies a worker only while it runs.
+func (p *Pool) SubmitKeyed(key string, task Task) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if p.queueSize > 0 && p.QueueDepth() >= p.queueSize {
+		p.emit(PoolEvent{Type: EventQueueFull, Time: time.Now()})
+		return ErrQueueFull
+	}
+
+	p.keyMu.Lock()
+	if p.keys == nil {
+		p.keys = make(map[string][]Task)
+	}
+	if pending, busy := p.keys[key]; busy {
+		p.wg.Add(1)
+		atomic.AddInt64(&p.queued, 1)
+		p.keys[key] = append(pending, task)
+		p.keyMu.Unlock()
+		return nil
+	}
+	p.keys[key] = nil
+	p.keyMu.Unlock()
+
+	p.enqueue(p.keyedTask(key, task))
 	return nil
 }
 
+// keyedTask wraps task so the next task of key is scheduled once it ends
+func (p *Pool) keyedTask(key string, task Task) func() error {
+	return func() error {
+		defer p.nextKeyed(key)
+		return task()
+	}
+}
+
+// nextKeyed schedules the next waiting task of key, or releases the key
+// if none is waiting
+func (p *Pool) nextKeyed(key string) {
+	p.keyMu.Lock()
+	pending := p.keys[key]
+	if len(pending) == 0 {
+		delete(p.keys, key)
+		p.keyMu.Unlock()
+		return
+	}
+	next := pending[0]
+	p.keys[key] = pending[1:]
+	p.keyMu.Unlock()
+
+	atomic.AddInt64(&p.queued, -1)
+	p.enqueue(p.keyedTask(key, next))
+	p.wg.Done()
+}
+
+// run executes a task, logging its lifecycle when the pool has a logger
+// and recording it when the pool has metrics or an events subscriber. A
+// panic is recovered only when the pool has a logger or panic handler.
+func (p *Pool) run(task func() error) {
+	if p.log == nil && p.panicHandler == nil && p.metrics == nil && !p.subscribed() {
+		_ = task()
+		return
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	if p.log != nil || p.panicHandler != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				err := fmt.Errorf("panic: %v", r)
+				p.recordTask("panic", start, err)
+				if p.log != nil {
+					p.log.Error(ctx, "task panicked", err,
+						logger.Field{Key: "panic", Value: r},
+						logger.Field{Key: "duration", Value: time.Since(start).String()},
+					)
+				}
+				if p.panicHandler != nil {
+					p.panicHandler(r)
+				}
+			}
+		}()
+	}
+
+	p.emit(PoolEvent{Type: EventTaskStarted, Time: start})
+	if p.log != nil {
+		p.log.Debug(ctx, "task started")
+	}
+	if err := task(); err != nil {
+		p.recordTask("failure", start, err)
+		if p.log != nil {
+			p.log.Error(ctx, "task failed", err,
+				logger.Field{Key: "duration", Value: time.Since(start).String()},
+			)
+		}
+		return
+	}
+	p.recordTask("success", start, nil)
+	if p.log != nil {
+		p.log.Debug(ctx, "task finished",
+			logger.Field{Key: "duration", Value: time.Since(start).String()},
+		)
+	}
+}
+
+// recordTask emits the event for a finished task and records it with the
+// given result when the pool has metrics
+func (p *Pool) recordTask(result string, start time.Time, err error) {
+	duration := time.Since(start)
+	event := PoolEvent{Type: EventTaskFinished, Time: time.Now(), Duration: duration}
+	if err != nil {
+		event.Type = EventTaskFailed
+		event.Err = err
+	}
+	p.emit(event)
+
+	if p.metrics == nil {
+		return
+	}
+	p.metrics.IncrementCounter(poolTasksMetric, 1, metrics.Labels{"result": result})
+	p.metrics.ObserveHistogram(poolTaskDurationMetric, duration.Seconds(), nil)
+}
+
+// SubmitAll submits each task to the pool, stopping at and returning the
+// first submission error
+func (p *Pool) SubmitAll(tasks []Task) error {
+	for _, task := range tasks {
+		if err := p.Submit(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunAll runs tasks on the pool and waits for them to complete, returning
+// each task's error at the task's index. If ctx is cancelled first, tasks
+// that have not finished report ctx.Err().
+func (p *Pool) RunAll(ctx context.Context, tasks []Task) []error {
+	fns := make([]func() (interface{}, error), len(tasks))
+	for i, task := range tasks {
+		task := task
+		fns[i] = func() (interface{}, error) { return nil, task() }
+	}
+
+	errs := make([]error, len(tasks))
+	finished := make([]bool, len(tasks))
+	results := p.SubmitStream(ctx, fns)
+	for {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				return errs
+			}
+			errs[res.Index] = res.Err
+			finished[res.Index] = true
+		case <-ctx.Done():
+			for i := range errs {
+				if !finished[i] {
+					errs[i] = ctx.Err()
+				}
+			}
+			return errs
+		}
+	}
+}
+
+// RunAllJoined runs tasks like RunAll and combines the task errors with
+// errors.Join, returning nil if every task succeeded
+func (p *Pool) RunAllJoined(ctx context.Context, tasks []Task) error {
+	return apperrors.Join(p.RunAll(ctx, tasks)...)
+}
+
+// SubmitStream submits tasks to the pool and returns a channel that emits each
+// result as its task completes. The channel is buffered for every task so
+// workers never block on a slow reader, and it is closed once all tasks are
+// done. Tasks that have not started when ctx is cancelled report ctx.Err().
+func (p *Pool) SubmitStream(ctx context.Context, tasks []func() (interface{}, error)) <-chan Result {
+	results := make(chan Result, len(tasks))
+
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		i, task := i, task
+		wg.Add(1)
+		err := p.Submit(func() error {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				results <- Result{Err: err, Index: i}
+				return err
+			}
+			value, err := task()
+			results <- Result{Value: value, Err: err, Index: i}
+			return err
+		})
+		if err != nil {
+			results <- Result{Err: err, Index: i}
+			wg.Done()
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
 // Close closes the pool and waits for all tasks to complete
 func (p *Pool) Close() {
 	p.mu.Lock()
 	p.closed = true
 	p.mu.Unlock()
 	p.wg.Wait()
+	p.stopWorkers()
+	p.closeEvents()
+
+	if p.log != nil {
+		p.log.Debug(context.Background(), "pool shut down")
+	}
+}
+
+// Shutdown closes the pool and waits for in-flight and queued tasks to
+// complete. If ctx ends first it returns ctx.Err(), and the remaining tasks
+// keep running in the background.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		p.stopWorkers()
+		p.closeEvents()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if p.log != nil {
+			p.log.Debug(ctx, "pool shut down")
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopWorkers stops the work-stealing workers, if any
+func (p *Pool) stopWorkers() {
+	if p.stealer != nil {
+		p.stealer.stop()
+	}
 }
 
 // ActiveTasks returns the number of active tasks
 func (p *Pool) ActiveTasks() int {
 	return len(p.workers)
 }
+
+// QueueDepth returns the number of submitted tasks waiting for a worker
+func (p *Pool) QueueDepth() int {
+	return int(atomic.LoadInt64(&p.queued))
+}
+
+// QueueCapacity returns the maximum queue depth, or 0 if the queue is
+// unbounded
+func (p *Pool) QueueCapacity() int {
+	return p.queueSize
+}
+
+// ReportQueueDepth sets the pool_queue_depth gauge on c every interval
+// until ctx is cancelled. The interval must be positive.
+func (p *Pool) ReportQueueDepth(ctx context.Context, c metrics.Collector, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("queue depth interval must be positive, got %s", interval)
+	}
+	if err := c.Register("pool_queue_depth", metrics.Gauge, "Tasks submitted to the pool but not yet started"); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			c.SetGauge("pool_queue_depth", float64(p.QueueDepth()), nil)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,444 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+	"order-system/pkg/platform/logger"
+	"order-system/pkg/platform/metrics"
+)
+
+// logEntry is an entry captured by recordingLogger
+type logEntry struct {
+	level  logger.Level
+	msg    string
+	err    error
+	fields []logger.Field
+}
+
+// field returns the value of the entry's field key
+func (e logEntry) field(key string) interface{} {
+	for _, f := range e.fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+// recordingLogger is a logger.Logger that keeps every entry
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordingLogger) record(level logger.Level, msg string, err error, fields []logger.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{level: level, msg: msg, err: err, fields: fields})
+}
+
+// logged returns the entries recorded so far
+func (l *recordingLogger) logged() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), l.entries...)
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Debug, msg, nil, fields)
+}
+func (l *recordingLogger) Info(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Info, msg, nil, fields)
+}
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...logger.Field) {
+	l.record(logger.Warn, msg, nil, fields)
+}
+func (l *recordingLogger) Error(ctx context.Context, msg string, err error, fields ...logger.Field) {
+	l.record(logger.Error, msg, err, fields)
+}
+func (l *recordingLogger) WithComponent(component string) logger.Logger { return l }
+func (l *recordingLogger) WithComponentFields(component string, fields ...logger.Field) logger.Logger {
+	return l
+}
+func (l *recordingLogger) WithFields(fields ...logger.Field) logger.Logger { return l }
+func (l *recordingLogger) Close() error                                    { return nil }
+
+// receive returns the next value from ch, failing the test after a second
+func receive[T any](t *testing.T, ch <-chan T) (T, bool) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting on channel")
+		var zero T
+		return zero, false
+	}
+}
+
+func TestSubmitStreamDeliversInCompletionOrder(t *testing.T) {
+	p := NewPool(2)
+	defer p.Close()
+
+	release := make(chan struct{})
+	results := p.SubmitStream(context.Background(), []func() (interface{}, error){
+		func() (interface{}, error) { <-release; return "slow", nil },
+		func() (interface{}, error) { return "fast", nil },
+	})
+
+	first, ok := receive(t, results)
+	require.True(t, ok)
+	assert.Equal(t, Result{Value: "fast", Index: 1}, first)
+
+	close(release)
+	second, ok := receive(t, results)
+	require.True(t, ok)
+	assert.Equal(t, Result{Value: "slow", Index: 0}, second)
+
+	_, ok = receive(t, results)
+	assert.False(t, ok, "channel closed after the last result")
+}
+
+func TestSubmitStreamClosesEmptyStream(t *testing.T) {
+	p := NewPool(1)
+	defer p.Close()
+
+	_, ok := receive(t, p.SubmitStream(context.Background(), nil))
+	assert.False(t, ok)
+}
+
+func TestPoolLogsRecoveredPanic(t *testing.T) {
+	log := &recordingLogger{}
+	p := NewPool(1, WithLogger(log))
+
+	require.NoError(t, p.Submit(func() error { panic("boom") }))
+	p.Close()
+
+	var panics []logEntry
+	for _, e := range log.logged() {
+		if e.level == logger.Error {
+			panics = append(panics, e)
+		}
+	}
+	require.Len(t, panics, 1)
+	assert.Equal(t, "task panicked", panics[0].msg)
+	assert.Equal(t, "boom", panics[0].field("panic"))
+	assert.ErrorContains(t, panics[0].err, "boom")
+}
+
+func TestPoolLogsLifecycleAtDebug(t *testing.T) {
+	log := &recordingLogger{}
+	p := NewPool(1, WithLogger(log))
+
+	require.NoError(t, p.Submit(func() error { return nil }))
+	p.Close()
+
+	var msgs []string
+	for _, e := range log.logged() {
+		assert.Equal(t, logger.Debug, e.level)
+		msgs = append(msgs, e.msg)
+	}
+	assert.Equal(t, []string{"task started", "task finished", "pool shut down"}, msgs)
+}
+
+// occupy runs a task on p that blocks until the returned func is called,
+// and waits for it to start
+func occupy(t *testing.T, p *Pool) (release func()) {
+	t.Helper()
+	started, done := make(chan struct{}), make(chan struct{})
+	require.NoError(t, p.Submit(func() error {
+		close(started)
+		<-done
+		return nil
+	}))
+	receive(t, started)
+	return func() { close(done) }
+}
+
+func TestSubmitAllStopsAtFirstSubmissionError(t *testing.T) {
+	p := NewPool(1, WithQueueSize(1))
+	release := occupy(t, p)
+
+	var ran int32
+	task := func() error { atomic.AddInt32(&ran, 1); return nil }
+	err := p.SubmitAll([]Task{task, task, task})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	release()
+	p.Close()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ran), "only the task before the failure was submitted")
+	assert.ErrorIs(t, p.SubmitAll([]Task{task}), ErrPoolClosed)
+}
+
+func TestRunAllCollectsErrorsInIndexOrder(t *testing.T) {
+	p := NewPool(3)
+	defer p.Close()
+	errSecond := errors.New("second")
+
+	errs := p.RunAll(context.Background(), []Task{
+		func() error { time.Sleep(10 * time.Millisecond); return nil },
+		func() error { return errSecond },
+		func() error { return nil },
+	})
+
+	assert.Equal(t, []error{nil, errSecond, nil}, errs)
+	assert.ErrorIs(t, apperrors.Join(errs...), errSecond)
+}
+
+func TestRunAllReportsContextErrorForUnfinishedTasks(t *testing.T) {
+	p := NewPool(1)
+	release := occupy(t, p)
+	defer p.Close()
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	errs := p.RunAll(ctx, []Task{func() error { return nil }, func() error { return nil }})
+
+	assert.Equal(t, []error{context.DeadlineExceeded, context.DeadlineExceeded}, errs)
+}
+
+func TestQueueDepthCountsTasksWaitingForAWorker(t *testing.T) {
+	p := NewPool(1, WithQueueSize(5))
+	assert.Equal(t, 5, p.QueueCapacity())
+	release := occupy(t, p)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Submit(func() error { return nil }))
+	}
+	assert.Equal(t, 3, p.QueueDepth(), "the running task is not queued")
+
+	release()
+	p.Close()
+	assert.Zero(t, p.QueueDepth())
+}
+
+func TestReportQueueDepthSetsGauge(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	c, err := metrics.New(cfg)
+	require.NoError(t, err)
+
+	p := NewPool(1)
+	release := occupy(t, p)
+	defer p.Close()
+	defer release()
+	require.NoError(t, p.Submit(func() error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, p.ReportQueueDepth(ctx, c, time.Millisecond))
+	assert.Eventually(t, func() bool { return c.GetGauge("pool_queue_depth", nil) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestReportQueueDepthRejectsNonPositiveInterval(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	c, err := metrics.New(cfg)
+	require.NoError(t, err)
+	p := NewPool(1)
+	defer p.Close()
+
+	assert.Error(t, p.ReportQueueDepth(context.Background(), c, 0))
+	assert.Error(t, p.ReportQueueDepth(context.Background(), c, -time.Second))
+	assert.NoError(t, c.Register("pool_queue_depth", metrics.Gauge, "Depth"), "nothing was registered")
+}
+
+func TestShutdownReturnsDeadlineErrorForSlowTask(t *testing.T) {
+	p := NewPool(1)
+	release := occupy(t, p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := p.Shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "Shutdown did not wait for the task")
+	assert.ErrorIs(t, p.Submit(func() error { return nil }), ErrPoolClosed)
+
+	// The task still finishes in the background
+	release()
+	require.NoError(t, p.Shutdown(context.Background()))
+}
+
+func TestShutdownWaitsForInFlightTasks(t *testing.T) {
+	p := NewPool(2)
+	var finished int32
+	for i := 0; i < 4; i++ {
+		require.NoError(t, p.Submit(func() error {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&finished, 1)
+			return nil
+		}))
+	}
+
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&finished))
+}
+
+func TestSubmitKeyedSerializesPerKey(t *testing.T) {
+	p := NewPool(4)
+	keys := []string{"order-1", "order-2", "order-3"}
+	const perKey = 20
+
+	var mu sync.Mutex
+	running := make(map[string]int)
+	order := make(map[string][]int)
+	overlapped := false
+	for i := 0; i < perKey; i++ {
+		for _, key := range keys {
+			key, i := key, i
+			require.NoError(t, p.SubmitKeyed(key, func() error {
+				mu.Lock()
+				running[key]++
+				overlapped = overlapped || running[key] > 1
+				order[key] = append(order[key], i)
+				mu.Unlock()
+
+				time.Sleep(100 * time.Microsecond)
+
+				mu.Lock()
+				running[key]--
+				mu.Unlock()
+				return nil
+			}))
+		}
+	}
+	p.Close()
+
+	assert.False(t, overlapped, "two tasks of one key ran at once")
+	for _, key := range keys {
+		require.Len(t, order[key], perKey)
+		for i, got := range order[key] {
+			assert.Equal(t, i, got, "%s ran out of submission order", key)
+		}
+	}
+}
+
+func TestSubmitKeyedRunsDifferentKeysConcurrently(t *testing.T) {
+	p := NewPool(3)
+	defer p.Close()
+	keys := []string{"order-1", "order-2", "order-3"}
+
+	// Each task waits until every key's task has started, which only
+	// happens if they run at the same time
+	var started sync.WaitGroup
+	started.Add(len(keys))
+	allStarted := make(chan struct{})
+	go func() { started.Wait(); close(allStarted) }()
+
+	results := make(chan bool, len(keys))
+	for _, key := range keys {
+		require.NoError(t, p.SubmitKeyed(key, func() error {
+			started.Done()
+			select {
+			case <-allStarted:
+				results <- true
+			case <-time.After(time.Second):
+				results <- false
+			}
+			return nil
+		}))
+	}
+
+	for range keys {
+		ok, _ := receive(t, results)
+		assert.True(t, ok)
+	}
+}
+
+func TestFairPoolRunsTasksInSubmissionOrder(t *testing.T) {
+	p := NewPool(1, WithFairness())
+	release := occupy(t, p)
+
+	var mu sync.Mutex
+	var order []int
+	for i := 0; i < 50; i++ {
+		i := i
+		require.NoError(t, p.Submit(func() error {
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			return nil
+		}))
+	}
+	assert.Equal(t, 50, p.QueueDepth())
+	release()
+	p.Close()
+
+	want := make([]int, 50)
+	for i := range want {
+		want[i] = i
+	}
+	assert.Equal(t, want, order)
+}
+
+func TestPoolWithQueueSizeAndPanicHandler(t *testing.T) {
+	recovered := make(chan interface{}, 1)
+	p := NewPool(1, WithQueueSize(2), WithPanicHandler(func(r interface{}) { recovered <- r }))
+	release := occupy(t, p)
+
+	var ran int32
+	require.NoError(t, p.Submit(func() error { panic("boom") }))
+	require.NoError(t, p.Submit(func() error { atomic.AddInt32(&ran, 1); return nil }))
+	assert.ErrorIs(t, p.Submit(func() error { return nil }), ErrQueueFull)
+
+	release()
+	p.Close()
+	r, _ := receive(t, recovered)
+	assert.Equal(t, "boom", r)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&ran), "the pool keeps running after a panic")
+}
+
+func TestPoolWithMetricsRecordsTaskResults(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	c, err := metrics.New(cfg)
+	require.NoError(t, err)
+	p := NewPool(2, WithMetrics(c), WithPanicHandler(func(interface{}) {}))
+
+	require.NoError(t, p.Submit(func() error { return nil }))
+	require.NoError(t, p.Submit(func() error { return errors.New("declined") }))
+	require.NoError(t, p.Submit(func() error { panic("boom") }))
+	p.Close()
+
+	for _, result := range []string{"success", "failure", "panic"} {
+		assert.Equal(t, 1.0, c.GetCounter(poolTasksMetric, metrics.Labels{"result": result}), result)
+	}
+	assert.Len(t, c.GetHistogram(poolTaskDurationMetric, nil), 3)
+}
+
+func TestNewPoolWithoutOptions(t *testing.T) {
+	p := NewPool(2)
+	var ran int32
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(func() error { atomic.AddInt32(&ran, 1); return nil }))
+	}
+	p.Close()
+
+	assert.EqualValues(t, 10, atomic.LoadInt32(&ran))
+	assert.Zero(t, p.QueueCapacity())
+}
+
+func TestDeprecatedBoolOptionsForward(t *testing.T) {
+	p := NewPoolWithOptions(2, WorkStealing(true), Fair(true))
+	defer p.Close()
+	assert.True(t, p.workStealing)
+	assert.True(t, p.fair)
+
+	q := NewPool(2, WithWorkStealing(), WorkStealing(false), Fair(false))
+	defer q.Close()
+	assert.False(t, q.workStealing)
+	assert.False(t, q.fair)
+}
@@ -0,0 +1,29 @@
+package concurrent
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Sample is a counter value paired with the instant it was read
+type Sample struct {
+	Value int64
+	At    time.Time
+}
+
+// Sample returns the counter's current value together with the time it
+// was read
+func (c *Counter) Sample() (value int64, at time.Time) {
+	value = atomic.LoadInt64(&c.value)
+	return value, time.Now()
+}
+
+// Rate returns the per-second change from prev to s. It returns 0 when
+// s was not taken after prev.
+func (s Sample) Rate(prev Sample) float64 {
+	elapsed := s.At.Sub(prev.At)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Value-prev.Value) / elapsed.Seconds()
+}
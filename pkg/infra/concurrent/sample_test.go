@@ -0,0 +1,40 @@
+package concurrent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateOverKnownInterval(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	prev := Sample{Value: 100, At: start}
+	next := Sample{Value: 350, At: start.Add(500 * time.Millisecond)}
+
+	assert.Equal(t, 500.0, next.Rate(prev))
+	assert.Equal(t, -500.0, Sample{Value: 100, At: next.At.Add(500 * time.Millisecond)}.Rate(next))
+}
+
+func TestRateIsZeroWithoutElapsedTime(t *testing.T) {
+	s := Sample{Value: 10, At: time.Unix(1700000000, 0)}
+
+	assert.Zero(t, s.Rate(s))
+	assert.Zero(t, s.Rate(Sample{Value: 0, At: s.At.Add(time.Second)}), "prev taken after s")
+}
+
+func TestCounterSampleAcrossInterval(t *testing.T) {
+	c := NewCounter(0)
+	value, at := c.Sample()
+	first := Sample{Value: value, At: at}
+
+	c.Add(50)
+	time.Sleep(20 * time.Millisecond)
+	value, at = c.Sample()
+	second := Sample{Value: value, At: at}
+
+	assert.Equal(t, int64(50), second.Value)
+	assert.GreaterOrEqual(t, second.At.Sub(first.At), 20*time.Millisecond)
+	assert.InDelta(t, 50/second.At.Sub(first.At).Seconds(), second.Rate(first), 1e-9)
+	assert.LessOrEqual(t, second.Rate(first), 2500.0, "at most 50 over 20ms")
+}
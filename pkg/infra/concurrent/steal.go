@@ -0,0 +1,140 @@
+package concurrent
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// stealer schedules tasks on a fixed set of workers, each with a local
+// queue. A worker runs its own tasks in order and steals the newest task
+// of another worker when its queue is empty.
+type stealer struct {
+	queues []*localQueue
+	next   uint32 // round-robin index for new tasks
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending int // tasks queued across all local queues
+	stopped bool
+}
+
+// localQueue is a worker's queue of tasks
+type localQueue struct {
+	mu    sync.Mutex
+	tasks []func() error
+}
+
+// newStealer starts size workers, at least one, that run tasks through p.
+// It must be called before p accepts tasks.
+func newStealer(p *Pool, size int) *stealer {
+	if size < 1 {
+		size = 1
+	}
+	if cap(p.workers) < size {
+		p.workers = make(chan struct{}, size)
+	}
+
+	s := &stealer{queues: make([]*localQueue, size)}
+	s.cond = sync.NewCond(&s.mu)
+	for i := range s.queues {
+		s.queues[i] = &localQueue{}
+	}
+	for i := range s.queues {
+		go s.work(p, i)
+	}
+	return s
+}
+
+// push queues task on the next worker's queue and wakes an idle worker.
+// pending is raised before the task is published, so a worker that takes
+// it at once never drives the count below zero.
+func (s *stealer) push(task func() error) {
+	s.mu.Lock()
+	s.pending++
+	s.mu.Unlock()
+
+	i := atomic.AddUint32(&s.next, 1) % uint32(len(s.queues))
+	q := s.queues[i]
+	q.mu.Lock()
+	q.tasks = append(q.tasks, task)
+	q.mu.Unlock()
+	s.cond.Signal()
+}
+
+// stop makes workers exit once every queue is empty
+func (s *stealer) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// work runs tasks for worker i until the stealer is stopped
+func (s *stealer) work(p *Pool, i int) {
+	for {
+		task := s.take(i)
+		if task == nil {
+			s.mu.Lock()
+			for s.pending == 0 && !s.stopped {
+				s.cond.Wait()
+			}
+			done := s.pending == 0 && s.stopped
+			s.mu.Unlock()
+			if done {
+				return
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+
+		atomic.AddInt64(&p.queued, -1)
+		p.workers <- struct{}{}
+		p.run(task)
+		<-p.workers
+		p.wg.Done()
+	}
+}
+
+// take returns the oldest task of worker i, or else the newest task of
+// another worker, or nil if every queue is empty
+func (s *stealer) take(i int) func() error {
+	if task := s.queues[i].popFront(); task != nil {
+		return task
+	}
+	for n := 1; n < len(s.queues); n++ {
+		if task := s.queues[(i+n)%len(s.queues)].popBack(); task != nil {
+			return task
+		}
+	}
+	return nil
+}
+
+// popFront removes and returns the oldest task
+func (q *localQueue) popFront() func() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	task := q.tasks[0]
+	q.tasks[0] = nil
+	q.tasks = q.tasks[1:]
+	return task
+}
+
+// popBack removes and returns the newest task
+func (q *localQueue) popBack() func() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	last := len(q.tasks) - 1
+	task := q.tasks[last]
+	q.tasks[last] = nil
+	q.tasks = q.tasks[:last]
+	return task
+}
@@ -0,0 +1,96 @@
+package concurrent
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkStealingDrainsQueueOfBusyWorker(t *testing.T) {
+	p := NewPool(2, WithWorkStealing())
+	release := make(chan struct{})
+	require.NoError(t, p.Submit(func() error {
+		<-release
+		return nil
+	}))
+
+	done := make(chan struct{}, 6)
+	for i := 0; i < 6; i++ {
+		require.NoError(t, p.Submit(func() error {
+			done <- struct{}{}
+			return nil
+		}))
+	}
+
+	// Half the quick tasks are queued behind the blocked worker; the
+	// other worker has to steal them for all six to finish
+	for i := 0; i < 6; i++ {
+		_, ok := receive(t, done)
+		require.True(t, ok)
+	}
+	close(release)
+	p.Close()
+	assert.Zero(t, p.QueueDepth())
+}
+
+func TestWorkStealingRunsEveryTask(t *testing.T) {
+	p := NewPool(4, WithWorkStealing())
+	var ran int64
+	for i := 0; i < 200; i++ {
+		require.NoError(t, p.Submit(func() error {
+			atomic.AddInt64(&ran, 1)
+			return nil
+		}))
+	}
+	p.Close()
+
+	assert.EqualValues(t, 200, atomic.LoadInt64(&ran))
+	assert.Zero(t, p.ActiveTasks())
+}
+
+// skewedDurations returns n task durations where every tenth task is
+// fifty times slower than the rest
+func skewedDurations(n int) []time.Duration {
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		durations[i] = 20 * time.Microsecond
+		if i%10 == 0 {
+			durations[i] = time.Millisecond
+		}
+	}
+	return durations
+}
+
+// runSkewed submits one task per duration to a pool and waits for them
+func runSkewed(b *testing.B, durations []time.Duration, opts ...Option) {
+	b.Helper()
+	for i := 0; i < b.N; i++ {
+		p := NewPool(4, opts...)
+		var wg sync.WaitGroup
+		for _, d := range durations {
+			d := d
+			wg.Add(1)
+			if err := p.Submit(func() error {
+				defer wg.Done()
+				time.Sleep(d)
+				return nil
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		wg.Wait()
+		p.Close()
+	}
+}
+
+func BenchmarkPoolSkewedFIFO(b *testing.B) {
+	runSkewed(b, skewedDurations(200))
+}
+
+func BenchmarkPoolSkewedWorkStealing(b *testing.B) {
+	runSkewed(b, skewedDurations(200), WithWorkStealing())
+}
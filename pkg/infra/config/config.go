@@ -1,17 +1,24 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 )
 
 // Provider manages configuration loading and validation
 type Provider struct {
+	mu         sync.RWMutex
 	config     *Config
 	configPath string
+	onReload   []func(err error)
 }
 
 // NewProvider creates a new configuration provider
@@ -40,64 +47,212 @@ func (p *Provider) Load() error {
 		return fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// Decrypt "enc:" values
+	if err := decryptSecrets(config); err != nil {
+		return err
+	}
+
 	// Validate config
 	if err := p.validate(config); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	p.mu.Lock()
 	p.config = config
+	p.mu.Unlock()
 	return nil
 }
 
 // Get returns the loaded configuration
 func (p *Provider) Get() *Config {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.config
 }
 
-// validate performs configuration validation
+// ReloadOnSignal reloads the configuration whenever one of the given signals
+// (SIGHUP by default) is received, until ctx is cancelled. A failed reload
+// keeps the previous configuration; each outcome is passed to the OnReload
+// functions.
+func (p *Provider) ReloadOnSignal(ctx context.Context, sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	trigger := make(chan os.Signal, 1)
+	signal.Notify(trigger, sig...)
+
+	go func() {
+		defer signal.Stop(trigger)
+		p.reloadOn(ctx, trigger)
+	}()
+}
+
+// reloadOn reloads the configuration each time trigger fires, until ctx is
+// cancelled
+func (p *Provider) reloadOn(ctx context.Context, trigger <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-trigger:
+			p.Reload()
+		}
+	}
+}
+
+// Reload loads the configuration again, as a reload signal does, and passes
+// the outcome to the OnReload functions. A failed reload keeps the previous
+// configuration.
+func (p *Provider) Reload() error {
+	err := p.Load()
+	p.notifyReload(err)
+	return err
+}
+
+// OnReload registers fn to be called after every reload attempt with its
+// error, nil on success
+func (p *Provider) OnReload(fn func(err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onReload = append(p.onReload, fn)
+}
+
+// notifyReload calls the OnReload functions with the outcome of a reload
+func (p *Provider) notifyReload(err error) {
+	p.mu.RLock()
+	fns := p.onReload
+	p.mu.RUnlock()
+
+	for _, fn := range fns {
+		fn(err)
+	}
+}
+
+// validate performs configuration validation, reporting every invalid field
 func (p *Provider) validate(config *Config) error {
+	errs := &ValidationError{}
+
 	// Validate Database settings
-	if config.Database.MaxOpenConns <= 0 {
-		return fmt.Errorf("database.maxOpenConns must be positive")
+	if strings.TrimSpace(config.Database.Host) == "" {
+		errs.add("database.host", "is required")
 	}
-	if config.Database.MaxIdleConns <= 0 {
-		return fmt.Errorf("database.maxIdleConns must be positive")
+	if config.Database.Port <= 0 || config.Database.Port > 65535 {
+		errs.add("database.port", "must be between 1 and 65535")
 	}
-	if config.Database.MaxLifetime <= 0 {
-		return fmt.Errorf("database.maxLifetime must be positive")
+	validatePool(errs, config)
+	if config.Database.SlowQueryThreshold < 0 {
+		errs.add("database.slowQueryThreshold", "must not be negative")
 	}
 
 	// Validate HTTP settings
 	if config.HTTP.Port <= 0 || config.HTTP.Port > 65535 {
-		return fmt.Errorf("http.port must be between 1 and 65535")
+		errs.add("http.port", "must be between 1 and 65535")
 	}
 	if config.HTTP.ReadTimeout <= 0 {
-		return fmt.Errorf("http.readTimeout must be positive")
+		errs.add("http.readTimeout", "must be positive")
 	}
 	if config.HTTP.WriteTimeout <= 0 {
-		return fmt.Errorf("http.writeTimeout must be positive")
+		errs.add("http.writeTimeout", "must be positive")
+	}
+	if config.HTTP.RequestTimeout < 0 {
+		errs.add("http.requestTimeout", "must not be negative")
+	}
+	if config.HTTP.WriteTimeout > 0 && config.HTTP.RequestTimeout > config.HTTP.WriteTimeout {
+		errs.add("http.requestTimeout", fmt.Sprintf("must not exceed http.writeTimeout (%s > %s)",
+			config.HTTP.RequestTimeout, config.HTTP.WriteTimeout))
+	}
+	if config.HTTP.ShutdownTimeout < 0 {
+		errs.add("http.shutdownTimeout", "must be positive when set")
+	}
+	if config.HTTP.RetryBudgetRatio < 0 || config.HTTP.RetryBudgetRatio > 1 {
+		errs.add("http.retryBudgetRatio", "must be between 0 and 1")
+	}
+	switch config.HTTP.Protocol {
+	case "", "http1", "http2", "h2c":
+	default:
+		errs.add("http.protocol", fmt.Sprintf("invalid protocol: %s", config.HTTP.Protocol))
 	}
 
 	// Validate Logger settings
 	level := strings.ToLower(config.Logger.Level)
 	validLevels := map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
 	if !validLevels[level] {
-		return fmt.Errorf("invalid logger.level: %s", config.Logger.Level)
+		errs.add("logger.level", fmt.Sprintf("invalid level: %s", config.Logger.Level))
+	}
+	switch config.Logger.Format {
+	case "", "json", "text":
+	default:
+		errs.add("logger.format", fmt.Sprintf("invalid format: %s", config.Logger.Format))
 	}
 
 	// Validate Metrics settings
 	if config.Metrics.Enabled {
 		if config.Metrics.Endpoint == "" {
-			return fmt.Errorf("metrics.endpoint is required when metrics are enabled")
+			errs.add("metrics.endpoint", "is required when metrics are enabled")
 		}
 		if config.Metrics.Interval <= 0 {
-			return fmt.Errorf("metrics.interval must be positive")
+			errs.add("metrics.interval", "must be positive")
+		}
+		switch config.Metrics.NegativeCounters {
+		case "", "reject", "clamp":
+		default:
+			errs.add("metrics.negativeCounters", fmt.Sprintf("invalid mode: %s", config.Metrics.NegativeCounters))
 		}
 	}
+	validateURL(errs, "metrics.endpoint", config.Metrics.Endpoint)
+	validateURL(errs, "metrics.pushGateway", config.Metrics.PushGateway)
 
+	if len(errs.Errors) > 0 {
+		return errs
+	}
 	return nil
 }
 
+// ValidatePool reports every invalid database connection pool setting in
+// config. It is the part of load-time validation that applies when new
+// pool limits are given to a live database.
+func ValidatePool(config *Config) error {
+	errs := &ValidationError{}
+	validatePool(errs, config)
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validatePool reports invalid database connection pool settings
+func validatePool(errs *ValidationError, config *Config) {
+	if config.Database.MaxOpenConns <= 0 {
+		errs.add("database.maxOpenConns", "must be positive")
+	}
+	if config.Database.MaxIdleConns <= 0 {
+		errs.add("database.maxIdleConns", "must be positive")
+	}
+	if config.Database.MaxLifetime <= 0 {
+		errs.add("database.maxLifetime", "must be positive")
+	}
+	if config.Database.AcquireTimeout < 0 {
+		errs.add("database.acquireTimeout", "must not be negative")
+	}
+}
+
+// validateURL reports a non-empty value that is not an absolute URL with a
+// scheme and host
+func validateURL(errs *ValidationError, field, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		errs.add(field, fmt.Sprintf("invalid URL %q: %v", value, err))
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		errs.add(field, fmt.Sprintf("invalid URL %q: must include a scheme and host", value))
+	}
+}
+
 // GetConfigPath returns the absolute path for a config file
 func (p *Provider) GetConfigPath(env string) string {
 	if env == "" {
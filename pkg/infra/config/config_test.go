@@ -0,0 +1,244 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a configuration document that passes validation
+func validConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost", "port": 3306, "user": "app", "database": "orders",
+			"maxOpenConns": 10, "maxIdleConns": 5, "maxLifetime": int64(time.Hour),
+		},
+		"http": map[string]interface{}{
+			"port": 8080, "readTimeout": int64(time.Second), "writeTimeout": int64(time.Second),
+		},
+		"logger": map[string]interface{}{"level": "info"},
+	}
+}
+
+// writeConfig writes doc to a config file in dir and returns its path
+func writeConfig(t *testing.T, dir string, doc map[string]interface{}) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, mustJSON(t, doc), 0o600))
+	return path
+}
+
+// reloadOnce fires trigger and waits for the reload it causes
+func reloadOnce(t *testing.T, trigger chan os.Signal, done <-chan error) error {
+	t.Helper()
+	trigger <- os.Interrupt
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("reload not observed")
+		return nil
+	}
+}
+
+func TestReloadOnTriggerUpdatesConfig(t *testing.T) {
+	dir := t.TempDir()
+	doc := validConfig()
+	p := NewProvider(writeConfig(t, dir, doc))
+	require.NoError(t, p.Load())
+
+	done := make(chan error, 1)
+	p.OnReload(func(err error) { done <- err })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trigger := make(chan os.Signal)
+	go p.reloadOn(ctx, trigger)
+
+	doc["logger"] = map[string]interface{}{"level": "debug"}
+	writeConfig(t, dir, doc)
+	require.NoError(t, reloadOnce(t, trigger, done))
+	assert.Equal(t, "debug", p.Get().Logger.Level)
+
+	doc["logger"] = map[string]interface{}{"level": "loud"}
+	writeConfig(t, dir, doc)
+	assert.Error(t, reloadOnce(t, trigger, done))
+	assert.Equal(t, "debug", p.Get().Logger.Level, "failed reload keeps the previous config")
+}
+
+func TestValidateReportsEveryInvalidField(t *testing.T) {
+	cfg := parsedValidConfig(t)
+	cfg.Database.MaxOpenConns = 0
+	cfg.HTTP.Port = 70000
+	cfg.Logger.Level = "loud"
+
+	assert.ElementsMatch(t, []string{"database.maxOpenConns", "http.port", "logger.level"}, validatedFields(t, cfg))
+}
+
+// mustJSON encodes v as JSON
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
+
+// validatedFields returns the fields validate reports for cfg
+func validatedFields(t *testing.T, cfg *Config) []string {
+	t.Helper()
+	return errorFields(t, NewProvider("").validate(cfg))
+}
+
+// errorFields returns the fields reported by a *ValidationError, or nil
+// for a nil err
+func errorFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	fields := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		fields[i] = fe.Field
+	}
+	return fields
+}
+
+// parsedValidConfig returns validConfig decoded into a Config
+func parsedValidConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg := &Config{}
+	require.NoError(t, json.Unmarshal(mustJSON(t, validConfig()), cfg))
+	return cfg
+}
+
+func TestValidateHTTPTimeoutOrdering(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		fields []string
+	}{
+		{
+			name: "valid baseline",
+			mutate: func(c *Config) {
+				c.HTTP.RequestTimeout = time.Second
+				c.HTTP.ShutdownTimeout = 5 * time.Second
+			},
+		},
+		{
+			name:   "request timeout exceeds write timeout",
+			mutate: func(c *Config) { c.HTTP.RequestTimeout = 2 * time.Second },
+			fields: []string{"http.requestTimeout"},
+		},
+		{
+			name:   "negative request timeout",
+			mutate: func(c *Config) { c.HTTP.RequestTimeout = -time.Second },
+			fields: []string{"http.requestTimeout"},
+		},
+		{
+			name:   "negative shutdown timeout",
+			mutate: func(c *Config) { c.HTTP.ShutdownTimeout = -time.Second },
+			fields: []string{"http.shutdownTimeout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parsedValidConfig(t)
+			tt.mutate(cfg)
+			assert.Equal(t, tt.fields, validatedFields(t, cfg))
+		})
+	}
+}
+
+func TestValidatePool(t *testing.T) {
+	cfg := parsedValidConfig(t)
+	assert.NoError(t, ValidatePool(cfg))
+
+	cfg.Database.MaxIdleConns = 0
+	assert.Equal(t, []string{"database.maxIdleConns"}, errorFields(t, ValidatePool(cfg)))
+	assert.Equal(t, []string{"database.maxIdleConns"}, validatedFields(t, cfg), "load-time validation agrees")
+
+	cfg.Database.MaxOpenConns = 0
+	cfg.Database.MaxIdleConns = -1
+	cfg.Database.Host = ""
+	assert.Equal(t, []string{"database.maxOpenConns", "database.maxIdleConns"}, errorFields(t, ValidatePool(cfg)),
+		"only pool settings are checked")
+}
+
+func TestValidateMetricsURLs(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		failed bool
+	}{
+		{name: "http with port", value: "http://localhost:9091"},
+		{name: "https with path", value: "https://push.example.com/metrics"},
+		{name: "missing scheme", value: "push.example.com:9091", failed: true},
+		{name: "missing host", value: "http:///metrics", failed: true},
+		{name: "relative path", value: "/metrics", failed: true},
+		{name: "unparseable", value: "http://[::1", failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parsedValidConfig(t)
+			cfg.Metrics.Endpoint = tt.value
+			cfg.Metrics.PushGateway = tt.value
+
+			err := NewProvider("").validate(cfg)
+
+			if !tt.failed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, []string{"metrics.endpoint", "metrics.pushGateway"}, errorFields(t, err))
+			assert.ErrorContains(t, err, "invalid URL")
+		})
+	}
+}
+
+func TestValidateDatabaseHostAndPort(t *testing.T) {
+	tests := []struct {
+		name   string
+		host   string
+		port   int
+		fields []string
+	}{
+		{name: "valid", host: "db.internal", port: 3306},
+		{name: "lowest port", host: "db.internal", port: 1},
+		{name: "highest port", host: "db.internal", port: 65535},
+		{name: "empty host", host: "", port: 3306, fields: []string{"database.host"}},
+		{name: "blank host", host: "  ", port: 3306, fields: []string{"database.host"}},
+		{name: "zero port", host: "db.internal", port: 0, fields: []string{"database.port"}},
+		{name: "negative port", host: "db.internal", port: -1, fields: []string{"database.port"}},
+		{name: "port out of range", host: "db.internal", port: 65536, fields: []string{"database.port"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parsedValidConfig(t)
+			cfg.Database.Host = tt.host
+			cfg.Database.Port = tt.port
+			assert.Equal(t, tt.fields, validatedFields(t, cfg))
+		})
+	}
+}
+
+func TestLoadRejectsMissingDatabaseHost(t *testing.T) {
+	doc := validConfig()
+	doc["database"].(map[string]interface{})["host"] = ""
+	p := NewProvider(writeConfig(t, t.TempDir(), doc))
+
+	err := p.Load()
+
+	assert.Equal(t, []string{"database.host"}, errorFields(t, err))
+	assert.Nil(t, p.Get(), "nothing is loaded for callers to connect with")
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// GetString returns the string at a dotted json path such as
+// "database.host", reporting false if the path is missing or not a string
+func (p *Provider) GetString(path string) (string, bool) {
+	v, ok := p.lookup(path)
+	if !ok || v.Kind() != reflect.String {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// GetInt returns the integer at a dotted json path such as "http.port",
+// reporting false if the path is missing or not an integer. Durations are
+// not integers here; use GetDuration.
+func (p *Provider) GetInt(path string) (int, bool) {
+	v, ok := p.lookup(path)
+	if !ok || v.Type() == durationType {
+		return 0, false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	default:
+		return 0, false
+	}
+}
+
+// GetDuration returns the duration at a dotted json path such as
+// "http.readTimeout", reporting false if the path is missing or not a
+// duration
+func (p *Provider) GetDuration(path string) (time.Duration, bool) {
+	v, ok := p.lookup(path)
+	if !ok || v.Type() != durationType {
+		return 0, false
+	}
+	return time.Duration(v.Int()), true
+}
+
+// GetBool returns the bool at a dotted json path such as
+// "metrics.enabled", reporting false if the path is missing or not a bool
+func (p *Provider) GetBool(path string) (bool, bool) {
+	v, ok := p.lookup(path)
+	if !ok || v.Kind() != reflect.Bool {
+		return false, false
+	}
+	return v.Bool(), true
+}
+
+// lookup follows a dotted json path through the loaded configuration
+func (p *Provider) lookup(path string) (reflect.Value, bool) {
+	cfg := p.Get()
+	if cfg == nil || path == "" {
+		return reflect.Value{}, false
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		field, ok := fieldByJSONName(v, name)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v = field
+	}
+	return v, true
+}
+
+// fieldByJSONName returns the exported field of struct v whose json tag
+// name is name
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if strings.Split(field.Tag.Get("json"), ",")[0] == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadedProvider returns a Provider that has loaded validConfig with
+// metrics enabled
+func loadedProvider(t *testing.T) *Provider {
+	t.Helper()
+	doc := validConfig()
+	doc["metrics"] = map[string]interface{}{
+		"enabled": true, "endpoint": "http://localhost:9091", "interval": int64(time.Minute),
+	}
+	p := NewProvider(writeConfig(t, t.TempDir(), doc))
+	require.NoError(t, p.Load())
+	return p
+}
+
+func TestGetByPathReadsEachType(t *testing.T) {
+	p := loadedProvider(t)
+
+	host, ok := p.GetString("database.host")
+	assert.True(t, ok)
+	assert.Equal(t, "localhost", host)
+
+	port, ok := p.GetInt("http.port")
+	assert.True(t, ok)
+	assert.Equal(t, 8080, port)
+
+	timeout, ok := p.GetDuration("http.readTimeout")
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, timeout)
+
+	enabled, ok := p.GetBool("metrics.enabled")
+	assert.True(t, ok)
+	assert.True(t, enabled)
+}
+
+func TestGetByPathMissingOrMistyped(t *testing.T) {
+	p := loadedProvider(t)
+
+	_, ok := p.GetString("database.hostname")
+	assert.False(t, ok, "unknown field")
+	_, ok = p.GetString("database.host.name")
+	assert.False(t, ok, "path continues past a leaf")
+	_, ok = p.GetString("")
+	assert.False(t, ok)
+	_, ok = p.GetString("Database.Host")
+	assert.False(t, ok, "paths use json names")
+	_, ok = p.GetInt("database.host")
+	assert.False(t, ok, "a string is not an int")
+	_, ok = p.GetInt("http.readTimeout")
+	assert.False(t, ok, "a duration is not an int")
+	_, ok = p.GetDuration("http.port")
+	assert.False(t, ok, "an int is not a duration")
+	_, ok = p.GetBool("database")
+	assert.False(t, ok, "a section is not a bool")
+}
+
+func TestGetByPathBeforeLoad(t *testing.T) {
+	_, ok := NewProvider("").GetString("database.host")
+	assert.False(t, ok)
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaDraft is the JSON Schema dialect produced by Schema
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema returns a JSON Schema describing the configuration file. Field
+// names come from the json tags; required fields and enums come from the
+// schema tags. An enumfold tag matches its values without regard to case
+// and is exported as a pattern, since JSON Schema enums are case-sensitive.
+func (p *Provider) Schema() ([]byte, error) {
+	schema := configSchema()
+	schema["$schema"] = schemaDraft
+	schema["title"] = "Config"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// ValidateAgainstSchema validates a configuration document against the
+// schema returned by Schema, reporting every violation
+func (p *Provider) ValidateAgainstSchema(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("failed to parse config document: %w", err)
+	}
+
+	errs := &ValidationError{}
+	validateSchema(configSchema(), doc, "", errs)
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// configSchema builds the schema for Config
+func configSchema() map[string]interface{} {
+	return typeSchema(reflect.TypeOf(Config{}))
+}
+
+// typeSchema builds the schema for a Go type
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == durationType {
+		return map[string]interface{}{
+			"type":        "integer",
+			"description": "duration in nanoseconds",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+
+			prop := typeSchema(field.Type)
+			for _, opt := range strings.Split(field.Tag.Get("schema"), ",") {
+				switch {
+				case opt == "required":
+					required = append(required, name)
+				case strings.HasPrefix(opt, "enum="):
+					prop["enum"] = strings.Split(strings.TrimPrefix(opt, "enum="), "|")
+				case strings.HasPrefix(opt, "enumfold="):
+					values := strings.Split(strings.TrimPrefix(opt, "enumfold="), "|")
+					prop["pattern"] = foldPattern(values)
+					prop["description"] = "one of " + strings.Join(values, ", ") + " in any case"
+				}
+			}
+			properties[name] = prop
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// validateSchema validates a decoded JSON value against a schema built by
+// typeSchema, recording violations in errs
+func validateSchema(schema map[string]interface{}, value interface{}, path string, errs *ValidationError) {
+	field := path
+	if field == "" {
+		field = "(root)"
+	}
+
+	schemaType, _ := schema["type"].(string)
+	if schemaType != "" && !matchesType(schemaType, value) {
+		errs.add(field, fmt.Sprintf("must be of type %s", schemaType))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]string); ok {
+		s, _ := value.(string)
+		found := false
+		for _, allowed := range enum {
+			if s == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs.add(field, fmt.Sprintf("must be one of %s", strings.Join(enum, ", ")))
+		}
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		s, _ := value.(string)
+		if !regexp.MustCompile(pattern).MatchString(s) {
+			if description, ok := schema["description"].(string); ok {
+				errs.add(field, "must be "+description)
+			} else {
+				errs.add(field, fmt.Sprintf("must match %s", pattern))
+			}
+		}
+	}
+
+	switch schemaType {
+	case "object":
+		obj := value.(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				errs.add(joinPath(path, name), "is required")
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v := obj[name]
+			if prop, ok := properties[name].(map[string]interface{}); ok {
+				validateSchema(prop, v, joinPath(path, name), errs)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs.add(joinPath(path, name), "is not a known field")
+				}
+			case map[string]interface{}:
+				validateSchema(additional, v, joinPath(path, name), errs)
+			}
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		for i, v := range value.([]interface{}) {
+			validateSchema(items, v, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+	}
+}
+
+// matchesType reports whether a decoded JSON value has the schema type
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return true
+	}
+}
+
+// joinPath appends a key to a dotted config path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// foldPattern returns a pattern matching any of values without regard to
+// case, written with character classes so it is valid in both Go and
+// ECMA-262 regular expressions
+func foldPattern(values []string) string {
+	alternatives := make([]string, len(values))
+	for i, v := range values {
+		var sb strings.Builder
+		for _, r := range v {
+			lower, upper := strings.ToLower(string(r)), strings.ToUpper(string(r))
+			if lower == upper {
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+				continue
+			}
+			sb.WriteString("[" + lower + upper + "]")
+		}
+		alternatives[i] = sb.String()
+	}
+	return "^(?:" + strings.Join(alternatives, "|") + ")$"
+}
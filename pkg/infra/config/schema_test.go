@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// property returns the schema of the dotted path in schema
+func property(t *testing.T, schema map[string]interface{}, path ...string) map[string]interface{} {
+	t.Helper()
+	for _, name := range path {
+		props, ok := schema["properties"].(map[string]interface{})
+		require.True(t, ok, "no properties at %s", name)
+		schema, ok = props[name].(map[string]interface{})
+		require.True(t, ok, "missing property %s", name)
+	}
+	return schema
+}
+
+func TestSchemaDescribesKnownFields(t *testing.T) {
+	data, err := NewProvider("").Schema()
+	require.NoError(t, err)
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	assert.Equal(t, schemaDraft, schema["$schema"])
+	assert.ElementsMatch(t, []interface{}{"database", "http", "logger"}, schema["required"])
+	assert.Equal(t, "string", property(t, schema, "database", "host")["type"])
+	assert.Equal(t, "integer", property(t, schema, "http", "readTimeout")["type"])
+	assert.Equal(t, "number", property(t, schema, "http", "retryBudgetRatio")["type"])
+	assert.Equal(t, []interface{}{"", "http1", "http2", "h2c"}, property(t, schema, "http", "protocol")["enum"])
+	assert.NotEmpty(t, property(t, schema, "logger", "level")["pattern"])
+	assert.Equal(t, "array", property(t, schema, "http", "redactHeaders")["type"])
+}
+
+func TestValidateAgainstSchemaAcceptsValidDocument(t *testing.T) {
+	doc := validConfig()
+	doc["logger"] = map[string]interface{}{"level": "INFO"}
+
+	assert.NoError(t, NewProvider("").ValidateAgainstSchema(mustJSON(t, doc)))
+	cfg := &Config{}
+	require.NoError(t, json.Unmarshal(mustJSON(t, doc), cfg))
+	assert.Empty(t, validatedFields(t, cfg), "validate accepts what the schema accepts")
+}
+
+func TestValidateAgainstSchemaReportsViolations(t *testing.T) {
+	doc := validConfig()
+	doc["http"].(map[string]interface{})["port"] = "8080"
+	doc["http"].(map[string]interface{})["protocol"] = "spdy"
+	doc["logger"] = map[string]interface{}{"level": "loud", "colour": true}
+	delete(doc["database"].(map[string]interface{}), "host")
+
+	err := NewProvider("").ValidateAgainstSchema(mustJSON(t, doc))
+
+	require.Error(t, err)
+	assert.ElementsMatch(t, []string{
+		"database.host", "http.port", "http.protocol", "logger.colour", "logger.level",
+	}, errorFields(t, err))
+}
+
+func TestSchemaAndValidateAgreeOnDatabaseFields(t *testing.T) {
+	for field, required := range map[string]bool{
+		"host": true, "port": true, "maxOpenConns": true, "maxIdleConns": true, "maxLifetime": true,
+		"user": false, "password": false, "database": false,
+	} {
+		doc := validConfig()
+		delete(doc["database"].(map[string]interface{}), field)
+		data := mustJSON(t, doc)
+
+		schemaErr := NewProvider("").ValidateAgainstSchema(data)
+		cfg := &Config{}
+		require.NoError(t, json.Unmarshal(data, cfg))
+		loadFields := validatedFields(t, cfg)
+
+		if required {
+			assert.Equal(t, []string{"database." + field}, errorFields(t, schemaErr), "schema rejects a missing %s", field)
+			assert.Equal(t, []string{"database." + field}, loadFields, "validate rejects a missing %s", field)
+		} else {
+			assert.NoError(t, schemaErr, "schema accepts a missing %s", field)
+			assert.Empty(t, loadFields, "validate accepts a missing %s", field)
+		}
+	}
+}
+
+func TestValidateAgainstSchemaRejectsMalformedJSON(t *testing.T) {
+	assert.ErrorContains(t, NewProvider("").ValidateAgainstSchema([]byte("{")), "failed to parse")
+}
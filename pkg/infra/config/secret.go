@@ -0,0 +1,138 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// encryptedPrefix marks a string value as AES-GCM ciphertext
+const encryptedPrefix = "enc:"
+
+// KeyEnv names the environment variable holding the base64-encoded
+// AES-128, AES-192 or AES-256 key for encrypted values
+const KeyEnv = "CONFIG_KEY"
+
+// EncryptValue encrypts plaintext with key and returns it in the
+// "enc:<base64>" form that Load decrypts
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecrets replaces every "enc:" string in config with its
+// plaintext. The key is only read from the environment when such a value
+// is present.
+func decryptSecrets(config *Config) error {
+	var gcm cipher.AEAD
+	return walkStrings(reflect.ValueOf(config).Elem(), "", func(path string, v reflect.Value) error {
+		value := v.String()
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			return nil
+		}
+
+		if gcm == nil {
+			key, err := keyFromEnv()
+			if err != nil {
+				return fmt.Errorf("%s is encrypted: %w", path, err)
+			}
+			if gcm, err = newGCM(key); err != nil {
+				return err
+			}
+		}
+
+		plaintext, err := decryptValue(gcm, strings.TrimPrefix(value, encryptedPrefix))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		v.SetString(plaintext)
+		return nil
+	})
+}
+
+// decryptValue opens base64-encoded nonce-prefixed ciphertext
+func decryptValue(gcm cipher.AEAD, encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// keyFromEnv reads and decodes the key in KeyEnv
+func keyFromEnv() ([]byte, error) {
+	encoded := os.Getenv(KeyEnv)
+	if encoded == "" {
+		return nil, fmt.Errorf("%s is not set", KeyEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", KeyEnv, err)
+	}
+	return key, nil
+}
+
+// newGCM creates an AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// walkStrings calls fn for every settable string reachable from v through
+// struct fields and slices, with its dotted json path
+func walkStrings(v reflect.Value, path string, fn func(path string, v reflect.Value) error) error {
+	switch v.Kind() {
+	case reflect.String:
+		return fn(path, v)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if err := walkStrings(v.Field(i), joinPath(path, name), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
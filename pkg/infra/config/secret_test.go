@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is a fixed AES-256 key
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// encryptedConfig writes validConfig with the database password encrypted
+// under testKey and returns its path
+func encryptedConfig(t *testing.T, password string) string {
+	t.Helper()
+	enc, err := EncryptValue(testKey, password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc, encryptedPrefix))
+
+	doc := validConfig()
+	doc["database"].(map[string]interface{})["password"] = enc
+	return writeConfig(t, t.TempDir(), doc)
+}
+
+func TestLoadDecryptsEncryptedValues(t *testing.T) {
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(testKey))
+	p := NewProvider(encryptedConfig(t, "s3cret"))
+
+	require.NoError(t, p.Load())
+	cfg := p.Get()
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+	assert.Equal(t, "app", cfg.Database.User, "unmarked values are left alone")
+}
+
+func TestLoadFailsWithoutKeyForEncryptedValue(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	p := NewProvider(encryptedConfig(t, "s3cret"))
+
+	err := p.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.password")
+	assert.Contains(t, err.Error(), KeyEnv+" is not set")
+	assert.Nil(t, p.Get())
+}
+
+func TestLoadDoesNotNeedKeyWithoutEncryptedValues(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	p := NewProvider(writeConfig(t, t.TempDir(), validConfig()))
+
+	assert.NoError(t, p.Load())
+}
+
+func TestLoadFailsWithWrongKey(t *testing.T) {
+	wrong := []byte("fedcba9876543210fedcba9876543210")
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(wrong))
+	p := NewProvider(encryptedConfig(t, "s3cret"))
+
+	err := p.Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt database.password")
+}
+
+func TestEncryptValueRejectsInvalidKey(t *testing.T) {
+	_, err := EncryptValue([]byte("short"), "s3cret")
+	assert.Error(t, err)
+}
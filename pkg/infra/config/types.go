@@ -1,41 +1,82 @@
 package config
 
 import (
+	"strings"
 	"time"
+
+	apperrors "order-system/pkg/infra/errors"
 )
 
 // Config represents the configuration settings
 type Config struct {
 	// Database settings
 	Database struct {
-		Host         string        `json:"host"`
-		Port         int           `json:"port"`
+		Host         string        `json:"host" schema:"required"`
+		Port         int           `json:"port" schema:"required"`
 		User         string        `json:"user"`
 		Password     string        `json:"password"`
 		Database     string        `json:"database"`
-		MaxOpenConns int           `json:"maxOpenConns"`
-		MaxIdleConns int           `json:"maxIdleConns"`
-		MaxLifetime  time.Duration `json:"maxLifetime"`
-	} `json:"database"`
+		MaxOpenConns int           `json:"maxOpenConns" schema:"required"`
+		MaxIdleConns int           `json:"maxIdleConns" schema:"required"`
+		MaxLifetime  time.Duration `json:"maxLifetime" schema:"required"`
+		// AcquireTimeout bounds how long a call or a beginning
+		// transaction waits for a pooled connection; zero waits for the
+		// caller's context
+		AcquireTimeout time.Duration `json:"acquireTimeout"`
+		// SlowQueryThreshold logs statements that take longer; zero
+		// disables slow-query logging
+		SlowQueryThreshold time.Duration `json:"slowQueryThreshold"`
+		// ExplainSlowQueries logs the EXPLAIN plan of slow SELECTs. It
+		// issues an extra query, so it is meant for debugging only.
+		ExplainSlowQueries bool `json:"explainSlowQueries"`
+		// LogArgs logs every statement with its bound arguments at debug
+		// level, after passing them through the configured ArgRedactor
+		LogArgs bool `json:"logArgs"`
+	} `json:"database" schema:"required"`
 
 	// HTTP settings
 	HTTP struct {
-		Port            int           `json:"port"`
-		ReadTimeout     time.Duration `json:"readTimeout"`
-		WriteTimeout    time.Duration `json:"writeTimeout"`
+		Port            int           `json:"port" schema:"required"`
+		ReadTimeout     time.Duration `json:"readTimeout" schema:"required"`
+		WriteTimeout    time.Duration `json:"writeTimeout" schema:"required"`
 		MaxHeaderBytes  int           `json:"maxHeaderBytes"`
 		MaxRequestSize  int64         `json:"maxRequestSize"`
 		RequestTimeout  time.Duration `json:"requestTimeout"`
 		ShutdownTimeout time.Duration `json:"shutdownTimeout"`
-	} `json:"http"`
+		// RetryBudgetRatio is the fraction of a retry, between 0 and 1,
+		// earned by each successful request across the client, so 0.1
+		// allows one retry per ten successes; zero disables the budget
+		RetryBudgetRatio float64 `json:"retryBudgetRatio"`
+		// Protocol selects the client protocols: "http1", "http2", "h2c"
+		// (HTTP/2 prior knowledge over cleartext), or empty to keep the
+		// default transport behaviour
+		Protocol string `json:"protocol" schema:"enum=|http1|http2|h2c"`
+		// DisableKeepAlives closes client connections after each request
+		DisableKeepAlives bool `json:"disableKeepAlives"`
+		// Debug logs request and response headers and bodies for every
+		// attempt at debug level
+		Debug bool `json:"debug"`
+		// RedactHeaders lists headers masked in debug logs; empty uses
+		// Authorization, Proxy-Authorization, Cookie and Set-Cookie
+		RedactHeaders []string `json:"redactHeaders"`
+	} `json:"http" schema:"required"`
 
 	// Logger settings
 	Logger struct {
-		Level      string `json:"level"`
-		Format     string `json:"format"`
+		Level  string `json:"level" schema:"required,enumfold=debug|info|warn|error"`
+		Format string `json:"format" schema:"enum=|json|text"`
+		// Output is "stdout", "stderr", a file path, or a syslog target:
+		// "syslog", "syslog://host:port" or "syslog+tcp://host:port"
 		Output     string `json:"output"`
 		TimeFormat string `json:"timeFormat"`
-	} `json:"logger"`
+		// FlattenFields emits fields at the top level of each entry instead
+		// of nested under "fields"
+		FlattenFields bool `json:"flattenFields"`
+		// Sync fsyncs a file output after every entry so a crash can't
+		// lose entries that were already logged. It is ignored for other
+		// outputs.
+		Sync bool `json:"sync"`
+	} `json:"logger" schema:"required"`
 
 	// Metrics settings
 	Metrics struct {
@@ -43,5 +84,54 @@ type Config struct {
 		Endpoint    string        `json:"endpoint"`
 		PushGateway string        `json:"pushGateway"`
 		Interval    time.Duration `json:"interval"`
+		// NegativeCounters selects how negative counter increments are
+		// handled: "reject" (default) or "clamp"
+		NegativeCounters string `json:"negativeCounters" schema:"enum=|reject|clamp"`
+		// Username and Password gate the metrics handler behind basic
+		// auth; leaving Username empty serves metrics without auth
+		Username string `json:"username"`
+		Password string `json:"password"`
+		// ResetOnCollect zeroes counters and clears histogram observations
+		// after each Collect, so every collection and sink push reports
+		// one interval. The metrics handler and Export read the series
+		// without resetting them.
+		ResetOnCollect bool `json:"resetOnCollect"`
 	} `json:"metrics"`
 }
+
+// FieldError describes a single invalid configuration field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError collects all configuration validation failures
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the field errors joined, so errors.As can reach a FieldError
+func (e *ValidationError) Unwrap() error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return apperrors.Join(errs...)
+}
+
+// add records a validation failure for the given field
+func (e *ValidationError) add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
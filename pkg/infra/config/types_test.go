@@ -0,0 +1,22 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrorUnwrapsToFieldErrors(t *testing.T) {
+	errs := &ValidationError{}
+	errs.add("http.port", "must be between 1 and 65535")
+	errs.add("logger.level", "invalid level: loud")
+	err := fmt.Errorf("invalid configuration: %w", errs)
+
+	var fe FieldError
+	require.True(t, errors.As(err, &fe))
+	assert.Equal(t, "http.port", fe.Field)
+	assert.True(t, errors.Is(err, FieldError{Field: "logger.level", Message: "invalid level: loud"}))
+}
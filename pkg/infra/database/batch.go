@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchInTx implements Database.BatchInTx. Items run in order, and a
+// failure is reported with the index of the item that caused it.
+func (d *db) BatchInTx(ctx context.Context, query string, argsList [][]interface{}) (int64, error) {
+	var total int64
+	err := d.Transaction(ctx, func(tx Transaction) error {
+		for i, args := range argsList {
+			affected, err := tx.ExecRows(ctx, query, args...)
+			if err != nil {
+				return fmt.Errorf("batch item %d: %w", i, err)
+			}
+			total += affected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const batchInsert = "INSERT INTO order_items (order_id, sku) VALUES (?, ?)"
+
+func TestBatchInTxCommitsEveryItem(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO order_items").WithArgs(1, "A").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^INSERT INTO order_items").WithArgs(1, "B").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("^INSERT INTO order_items").WithArgs(2, "C").WillReturnResult(noInsertIDResult{rows: 2})
+	mock.ExpectCommit()
+
+	total, err := d.BatchInTx(context.Background(), batchInsert, [][]interface{}{
+		{1, "A"}, {1, "B"}, {2, "C"},
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchInTxRollsBackOnFailure(t *testing.T) {
+	d, mock := newMockDB(t)
+	dup := errors.New("duplicate entry")
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO order_items").WithArgs(1, "A").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^INSERT INTO order_items").WithArgs(1, "A").WillReturnError(dup)
+	mock.ExpectRollback()
+
+	total, err := d.BatchInTx(context.Background(), batchInsert, [][]interface{}{
+		{1, "A"}, {1, "A"}, {2, "C"},
+	})
+
+	require.Error(t, err)
+	assert.Zero(t, total)
+	assert.ErrorIs(t, err, dup)
+	assert.Contains(t, err.Error(), "batch item 1")
+	assert.NoError(t, mock.ExpectationsWereMet(), "the third item never ran")
+}
+
+func TestBatchInTxWithNoItems(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	total, err := d.BatchInTx(context.Background(), batchInsert, nil)
+
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchInTxReportsBeginFailure(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin().WillReturnError(errors.New("too many connections"))
+
+	_, err := d.BatchInTx(context.Background(), batchInsert, [][]interface{}{{1, "A"}})
+
+	assert.ErrorContains(t, err, "too many connections")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"io"
+	"time"
+
+	"order-system/pkg/platform/trace"
+)
+
+// ExportCSV implements Database.ExportCSV. Rows are written as they are
+// read, NULLs become empty fields and every value is written as text.
+func (d *db) ExportCSV(ctx context.Context, w io.Writer, query string, args ...interface{}) (count int64, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.export_csv")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "export_csv", query, args)
+	defer func() { after(err) }()
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &count)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, &Error{
+			Operation: "query",
+			Query:     query,
+			Err:       err,
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, &Error{
+			Operation: "columns",
+			Query:     query,
+			Err:       err,
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return 0, &Error{Operation: "export_csv", Query: query, Err: err}
+	}
+
+	// RawBytes are reused by each Scan, so the record is rebuilt per row
+	values := make([]sql.RawBytes, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return count, &Error{
+				Operation: "scan",
+				Query:     query,
+				Err:       err,
+			}
+		}
+		for i, v := range values {
+			record[i] = string(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return count, &Error{Operation: "export_csv", Query: query, Err: err}
+		}
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return count, &Error{
+			Operation: "scan",
+			Query:     query,
+			Err:       err,
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return count, &Error{Operation: "export_csv", Query: query, Err: err}
+	}
+	return count, nil
+}
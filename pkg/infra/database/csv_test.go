@@ -0,0 +1,59 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCSVWritesHeaderAndRows(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id, customer, note FROM orders").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer", "note"}).
+			AddRow(1, "Ada", []byte("gift, wrapped")).
+			AddRow(2, "Grace", nil).
+			AddRow(3, `Bob "B"`, "rush"))
+
+	var buf bytes.Buffer
+	count, err := d.ExportCSV(context.Background(), &buf, "SELECT id, customer, note FROM orders WHERE status = ?", "paid")
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, "id,customer,note\n"+
+		"1,Ada,\"gift, wrapped\"\n"+
+		"2,Grace,\n"+
+		"3,\"Bob \"\"B\"\"\",rush\n", buf.String())
+}
+
+func TestExportCSVEmptyResultWritesHeader(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id FROM orders").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	var buf bytes.Buffer
+	count, err := d.ExportCSV(context.Background(), &buf, "SELECT id FROM orders")
+
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Equal(t, "id\n", buf.String())
+}
+
+func TestExportCSVReportsRowError(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).RowError(1, errors.New("conn reset")))
+
+	var buf bytes.Buffer
+	count, err := d.ExportCSV(context.Background(), &buf, "SELECT id FROM orders")
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "scan", dbErr.Operation)
+	assert.Equal(t, int64(1), count, "rows before the error were written")
+}
@@ -3,10 +3,18 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/logger"
+	"order-system/pkg/platform/metrics"
+	"order-system/pkg/platform/trace"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -19,15 +27,48 @@ type Database interface {
 	// Exec executes a query without returning any rows
 	Exec(ctx context.Context, query string, args ...interface{}) (*Result, error)
 
+	// ExecRows executes a query without returning any rows and returns the
+	// number of rows affected
+	ExecRows(ctx context.Context, query string, args ...interface{}) (int64, error)
+
+	// BatchInTx executes query once per argument set in a single
+	// transaction, returning the total rows affected. The first failure
+	// rolls back the whole batch.
+	BatchInTx(ctx context.Context, query string, argsList [][]interface{}) (int64, error)
+
 	// Query executes a query that returns rows
 	Query(ctx context.Context, query string, args ...interface{}) ([]Row, error)
 
 	// QueryRow executes a query that returns a single row
 	QueryRow(ctx context.Context, query string, args ...interface{}) Row
 
+	// QueryEach executes a query and calls fn for each row as it is read
+	QueryEach(ctx context.Context, query string, args []interface{}, fn func(Row) error) error
+
+	// QueryColumn scans the first column of every row into dest, which must
+	// be a pointer to a slice
+	QueryColumn(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+
+	// ExportCSV streams the result of a query to w as CSV with a header
+	// row, returning the number of data rows written
+	ExportCSV(ctx context.Context, w io.Writer, query string, args ...interface{}) (int64, error)
+
+	// Ping verifies the connection, honouring the deadline of ctx
+	Ping(ctx context.Context) error
+
+	// HealthCheck reports whether the database is reachable; it can be
+	// registered directly as a health check
+	HealthCheck(ctx context.Context) error
+
 	// Stats returns database statistics
 	Stats() Stats
 
+	// Use adds a hook that observes every database operation
+	Use(hook QueryHook)
+
+	// Reconfigure applies new connection pool limits without reconnecting
+	Reconfigure(cfg *config.Config) error
+
 	// Close closes the database connection
 	Close() error
 }
@@ -35,11 +76,22 @@ type Database interface {
 // db implements the Database interface
 type db struct {
 	*sql.DB
-	config *config.Config
+	config    atomic.Pointer[config.Config]
+	collector metrics.Collector
+	log       logger.Logger
+	redact    ArgRedactor
+	slowLog   *slowLog
+
+	// panicAsError returns a panic in a transaction function as an error
+	// after rolling back instead of re-panicking
+	panicAsError bool
+
+	hooksMu sync.RWMutex
+	hooks   []QueryHook
 }
 
 // New creates a new database connection
-func New(cfg *config.Config) (Database, error) {
+func New(cfg *config.Config, opts ...Option) (Database, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=Local",
 		cfg.Database.User,
 		cfg.Database.Password,
@@ -52,7 +104,7 @@ func New(cfg *config.Config) (Database, error) {
 	if err != nil {
 		return nil, &Error{
 			Operation: "open",
-			Err:       err,
+			Err:       redactDSN(err, dsn),
 		}
 	}
 
@@ -62,46 +114,77 @@ func New(cfg *config.Config) (Database, error) {
 	sqlDB.SetConnMaxLifetime(cfg.Database.MaxLifetime)
 
 	// Verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultPingTimeout)
 	defer cancel()
 
 	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
 		return nil, &Error{
 			Operation: "ping",
-			Err:       err,
+			Err:       redactDSN(err, dsn),
 		}
 	}
 
-	return &db{
-		DB:     sqlDB,
-		config: cfg,
-	}, nil
+	d := &db{DB: sqlDB}
+	d.config.Store(cfg)
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.registerMetrics()
+
+	return d, nil
 }
 
 // Transaction executes a function within a transaction
-func (d *db) Transaction(ctx context.Context, fn func(Transaction) error) error {
-	tx, err := d.DB.BeginTx(ctx, nil)
+func (d *db) Transaction(ctx context.Context, fn func(Transaction) error) (err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.transaction")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "transaction", "", nil)
+	defer func() { after(err) }()
+
+	tx, release, err := d.begin(ctx)
 	if err != nil {
-		return &Error{
-			Operation: "begin_transaction",
-			Err:       err,
-		}
+		return err
 	}
+	defer release()
+
+	// Duration covers the user function plus commit or rollback
+	start := time.Now()
+	defer func() { d.observeHistogram(metricTransactionDuration, time.Since(start).Seconds()) }()
 
 	// Create transaction wrapper
 	txWrapper := &transaction{
 		Tx: tx,
+		db: d,
 	}
 
 	// Execute function
-	if err := fn(txWrapper); err != nil {
+	if fnErr := callTxFunc(fn, txWrapper); fnErr != nil {
 		// Rollback on error
+		d.incrementCounter(metricTransactionRollbacks, nil)
+		var panicErr *PanicError
+		repanic := errors.As(fnErr, &panicErr) && !d.panicAsError
+
+		// Both errors stay in the chain when the rollback fails too
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return &Error{
+			err = &Error{
 				Operation: "rollback",
-				Err:       fmt.Errorf("rollback failed: %v (original error: %v)", rbErr, err),
+				Err:       fmt.Errorf("rollback failed: %w (original error: %w)", rbErr, fnErr),
+			}
+		} else {
+			err = &Error{
+				Operation: "transaction_rolled_back",
+				Err:       fnErr,
 			}
 		}
+
+		// Resume a panic once the connection is released, whether or not
+		// the rollback succeeded, unless it should be reported as an error.
+		// The named result is set first so the deferred span and hooks
+		// record the failure.
+		if repanic {
+			panic(panicErr.Value)
+		}
 		return err
 	}
 
@@ -112,13 +195,58 @@ func (d *db) Transaction(ctx context.Context, fn func(Transaction) error) error
 			Err:       err,
 		}
 	}
+	d.incrementCounter(metricTransactionCommits, nil)
 
 	return nil
 }
 
+// callTxFunc runs fn, converting a panic into a *PanicError so the
+// transaction can be rolled back
+func callTxFunc(fn func(Transaction) error, tx Transaction) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r}
+		}
+	}()
+	return fn(tx)
+}
+
+// TransactionValue executes fn within a transaction and returns its value
+// once the transaction commits. On rollback the zero value is returned.
+func TransactionValue[T any](ctx context.Context, d Database, fn func(Transaction) (T, error)) (T, error) {
+	var value T
+	err := d.Transaction(ctx, func(tx Transaction) error {
+		v, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		value = v
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
 // Exec executes a query without returning any rows
-func (d *db) Exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
-	result, err := d.DB.ExecContext(ctx, query, args...)
+func (d *db) Exec(ctx context.Context, query string, args ...interface{}) (_ *Result, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.exec")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "exec", query, args)
+	defer func() { after(err) }()
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var affected int64
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &affected)
+
+	result, err := conn.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, &Error{
 			Operation: "exec",
@@ -127,33 +255,90 @@ func (d *db) Exec(ctx context.Context, query string, args ...interface{}) (*Resu
 		}
 	}
 
-	lastInsertId, err := result.LastInsertId()
+	res, err := toResult(query, result)
+	if err == nil {
+		affected = res.RowsAffected
+	}
+	return res, err
+}
+
+// ExecRows executes a query and returns the number of rows affected. It
+// never calls LastInsertId.
+func (d *db) ExecRows(ctx context.Context, query string, args ...interface{}) (affected int64, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.exec")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "exec", query, args)
+	defer func() { after(err) }()
+
+	conn, release, err := d.acquire(ctx, query)
 	if err != nil {
-		return nil, &Error{
-			Operation: "last_insert_id",
-			Query:     query,
-			Err:       err,
-		}
+		return 0, err
 	}
+	defer release()
+
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &affected)
 
-	rowsAffected, err := result.RowsAffected()
+	result, err := conn.ExecContext(ctx, query, args...)
 	if err != nil {
-		return nil, &Error{
-			Operation: "rows_affected",
+		return 0, &Error{
+			Operation: "exec",
 			Query:     query,
 			Err:       err,
 		}
 	}
+	return rowsAffected(query, result)
+}
+
+// toResult converts a driver result. LastInsertId is unsupported for some
+// statements and tables, so a failure there leaves it zero rather than
+// failing a write that succeeded; only a RowsAffected failure is an error.
+func toResult(query string, result sql.Result) (*Result, error) {
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		lastInsertId = 0
+	}
+
+	affected, err := rowsAffected(query, result)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Result{
 		LastInsertId: lastInsertId,
-		RowsAffected: rowsAffected,
+		RowsAffected: affected,
 	}, nil
 }
 
+// rowsAffected reads the affected row count of a driver result
+func rowsAffected(query string, result sql.Result) (int64, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, &Error{
+			Operation: "rows_affected",
+			Query:     query,
+			Err:       err,
+		}
+	}
+	return affected, nil
+}
+
 // Query executes a query that returns rows
-func (d *db) Query(ctx context.Context, query string, args ...interface{}) ([]Row, error) {
-	rows, err := d.DB.QueryContext(ctx, query, args...)
+func (d *db) Query(ctx context.Context, query string, args ...interface{}) (_ []Row, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.query")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "query", query, args)
+	defer func() { after(err) }()
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var count int64
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &count)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, &Error{
 			Operation: "query",
@@ -166,6 +351,7 @@ func (d *db) Query(ctx context.Context, query string, args ...interface{}) ([]Ro
 	var result []Row
 	for rows.Next() {
 		result = append(result, rows)
+		count++
 	}
 
 	if err := rows.Err(); err != nil {
@@ -179,9 +365,194 @@ func (d *db) Query(ctx context.Context, query string, args ...interface{}) ([]Ro
 	return result, nil
 }
 
-// QueryRow executes a query that returns a single row
+// QueryEach executes a query and calls fn for each row without
+// materializing the result set, stopping at the first error fn returns
+func (d *db) QueryEach(ctx context.Context, query string, args []interface{}, fn func(Row) error) (err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.query_each")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "query_each", query, args)
+	defer func() { after(err) }()
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var count int64
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &count)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &Error{
+			Operation: "query",
+			Query:     query,
+			Err:       err,
+		}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		count++
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return &Error{
+			Operation: "scan",
+			Query:     query,
+			Err:       err,
+		}
+	}
+
+	return nil
+}
+
+// QueryColumn scans the first column of every row into dest, which must be
+// a pointer to a slice such as *[]int64 or *[]string
+func (d *db) QueryColumn(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.query_column")
+	defer func() { finish(err) }()
+	ctx, after := d.runHooks(ctx, "query_column", query, args)
+	defer func() { after(err) }()
+
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return &Error{
+			Operation: "query_column",
+			Query:     query,
+			Err:       fmt.Errorf("dest must be a non-nil pointer to a slice, got %T", dest),
+		}
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var count int64
+	defer d.observeQuery(ctx, conn, query, args, time.Now(), &count)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return &Error{
+			Operation: "query",
+			Query:     query,
+			Err:       err,
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err == nil && len(columns) == 0 {
+		err = fmt.Errorf("query returned no columns")
+	}
+	if err != nil {
+		return &Error{
+			Operation: "columns",
+			Query:     query,
+			Err:       err,
+		}
+	}
+
+	// Only the first column is kept; the rest are scanned and discarded
+	targets := make([]interface{}, len(columns))
+	for i := 1; i < len(targets); i++ {
+		targets[i] = new(interface{})
+	}
+
+	values := slice.Slice(0, slice.Len())
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		targets[0] = elem.Interface()
+		if err := rows.Scan(targets...); err != nil {
+			return &Error{
+				Operation: "scan",
+				Query:     query,
+				Err:       fmt.Errorf("cannot scan column %q into %s: %w", columns[0], elemType, err),
+			}
+		}
+		values = reflect.Append(values, elem.Elem())
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return &Error{
+			Operation: "scan",
+			Query:     query,
+			Err:       err,
+		}
+	}
+
+	slice.Set(values)
+	return nil
+}
+
+// QueryRow executes a query that returns a single row. The connection is
+// held until Scan is called.
 func (d *db) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
-	return d.DB.QueryRowContext(ctx, query, args...)
+	// Row errors surface lazily on Scan, so the statement finishes there
+	ctx, finish := trace.StartSpan(ctx, "db.query_row")
+	ctx, after := d.runHooks(ctx, "query_row", query, args)
+	r := &row{query: query}
+
+	conn, release, err := d.acquire(ctx, query)
+	if err != nil {
+		r.err = err
+		r.done = func(err error) {
+			after(err)
+			finish(err)
+		}
+		return r
+	}
+
+	start := time.Now()
+	r.Row = conn.QueryRowContext(ctx, query, args...)
+	r.done = func(err error) {
+		var count int64
+		if err == nil {
+			count = 1
+		}
+		d.observeQuery(ctx, conn, query, args, start, &count)
+		release()
+		after(err)
+		finish(err)
+	}
+	return r
+}
+
+// defaultPingTimeout bounds a ping whose context has no deadline
+const defaultPingTimeout = 5 * time.Second
+
+// Ping implements Database.Ping. The caller's deadline is used as is;
+// defaultPingTimeout applies only when ctx has none.
+func (d *db) Ping(ctx context.Context) (err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.ping")
+	defer func() { finish(err) }()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultPingTimeout)
+		defer cancel()
+	}
+
+	if err := d.DB.PingContext(ctx); err != nil {
+		return &Error{
+			Operation: "ping",
+			Err:       err,
+		}
+	}
+	return nil
+}
+
+// HealthCheck implements Database.HealthCheck
+func (d *db) HealthCheck(ctx context.Context) error {
+	return d.Ping(ctx)
 }
 
 // Stats returns database statistics
@@ -197,12 +568,75 @@ func (d *db) Stats() Stats {
 	}
 }
 
+// Reconfigure applies the pool limits from cfg to the live connection pool
+// and makes cfg the config later calls read their settings from. The pool
+// settings are validated first so an invalid config leaves the database
+// untouched.
+func (d *db) Reconfigure(cfg *config.Config) error {
+	if err := config.ValidatePool(cfg); err != nil {
+		return &Error{
+			Operation: "reconfigure",
+			Err:       err,
+		}
+	}
+
+	d.DB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	d.DB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	d.DB.SetConnMaxLifetime(cfg.Database.MaxLifetime)
+	d.config.Store(cfg)
+
+	return nil
+}
+
+// settings returns the config the database currently runs with
+func (d *db) settings() *config.Config {
+	return d.config.Load()
+}
+
+// row wraps *sql.Row so QueryRow reports a missing row as ErrNotFound
+// while keeping sql.ErrNoRows in the error chain
+type row struct {
+	*sql.Row
+	err   error // set instead of Row when the statement could not run
+	query string
+
+	// done finishes the statement with the outcome of the first Scan
+	done     func(err error)
+	doneOnce sync.Once
+}
+
+// Scan implements Row.Scan
+func (r *row) Scan(dest ...interface{}) error {
+	err := r.err
+	if err == nil {
+		err = r.Row.Scan(dest...)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		err = &Error{
+			Operation: "scan",
+			Query:     r.query,
+			Err:       fmt.Errorf("%w: %w", ErrNotFound, err),
+		}
+	}
+	r.doneOnce.Do(func() { r.done(err) })
+	return err
+}
+
 // transaction implements the Transaction interface
 type transaction struct {
 	*sql.Tx
+	db *db
 }
 
-func (t *transaction) Exec(ctx context.Context, query string, args ...interface{}) (*Result, error) {
+func (t *transaction) Exec(ctx context.Context, query string, args ...interface{}) (_ *Result, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.tx.exec")
+	defer func() { finish(err) }()
+	ctx, after := t.db.runHooks(ctx, "tx.exec", query, args)
+	defer func() { after(err) }()
+
+	var affected int64
+	defer t.db.observeQuery(ctx, t.Tx, query, args, time.Now(), &affected)
+
 	result, err := t.Tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nil, &Error{
@@ -212,31 +646,43 @@ func (t *transaction) Exec(ctx context.Context, query string, args ...interface{
 		}
 	}
 
-	lastInsertId, err := result.LastInsertId()
-	if err != nil {
-		return nil, &Error{
-			Operation: "last_insert_id",
-			Query:     query,
-			Err:       err,
-		}
+	res, err := toResult(query, result)
+	if err == nil {
+		affected = res.RowsAffected
 	}
+	return res, err
+}
+
+// ExecRows executes a query within the transaction and returns the number
+// of rows affected. It never calls LastInsertId.
+func (t *transaction) ExecRows(ctx context.Context, query string, args ...interface{}) (affected int64, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.tx.exec")
+	defer func() { finish(err) }()
+	ctx, after := t.db.runHooks(ctx, "tx.exec", query, args)
+	defer func() { after(err) }()
 
-	rowsAffected, err := result.RowsAffected()
+	defer t.db.observeQuery(ctx, t.Tx, query, args, time.Now(), &affected)
+
+	result, err := t.Tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		return nil, &Error{
-			Operation: "rows_affected",
+		return 0, &Error{
+			Operation: "exec",
 			Query:     query,
 			Err:       err,
 		}
 	}
-
-	return &Result{
-		LastInsertId: lastInsertId,
-		RowsAffected: rowsAffected,
-	}, nil
+	return rowsAffected(query, result)
 }
 
-func (t *transaction) Query(ctx context.Context, query string, args ...interface{}) ([]Row, error) {
+func (t *transaction) Query(ctx context.Context, query string, args ...interface{}) (_ []Row, err error) {
+	ctx, finish := trace.StartSpan(ctx, "db.tx.query")
+	defer func() { finish(err) }()
+	ctx, after := t.db.runHooks(ctx, "tx.query", query, args)
+	defer func() { after(err) }()
+
+	var count int64
+	defer t.db.observeQuery(ctx, t.Tx, query, args, time.Now(), &count)
+
 	rows, err := t.Tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, &Error{
@@ -250,6 +696,7 @@ func (t *transaction) Query(ctx context.Context, query string, args ...interface
 	var result []Row
 	for rows.Next() {
 		result = append(result, rows)
+		count++
 	}
 
 	if err := rows.Err(); err != nil {
@@ -264,5 +711,22 @@ func (t *transaction) Query(ctx context.Context, query string, args ...interface
 }
 
 func (t *transaction) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
-	return t.Tx.QueryRowContext(ctx, query, args...)
+	// Row errors surface lazily on Scan, so the statement finishes there
+	ctx, finish := trace.StartSpan(ctx, "db.tx.query_row")
+	ctx, after := t.db.runHooks(ctx, "tx.query_row", query, args)
+
+	start := time.Now()
+	return &row{
+		Row:   t.Tx.QueryRowContext(ctx, query, args...),
+		query: query,
+		done: func(err error) {
+			var count int64
+			if err == nil {
+				count = 1
+			}
+			t.db.observeQuery(ctx, t.Tx, query, args, start, &count)
+			after(err)
+			finish(err)
+		},
+	}
 }
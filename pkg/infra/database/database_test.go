@@ -0,0 +1,636 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/metrics"
+	"order-system/pkg/platform/trace"
+)
+
+// newMockDB returns a db backed by sqlmock, configured by opts
+func newMockDB(t *testing.T, opts ...Option) (*db, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	d := &db{DB: sqlDB}
+	d.config.Store(&config.Config{})
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.registerMetrics()
+	return d, mock
+}
+
+// recordSpans installs a recording global tracer for the test
+func recordSpans(t *testing.T) *[]trace.Span {
+	t.Helper()
+	var spans []trace.Span
+	trace.SetTracer(trace.NewRecorder(func(s trace.Span) { spans = append(spans, s) }))
+	t.Cleanup(func() { trace.SetTracer(nil) })
+	return &spans
+}
+
+// recordingHook records the error of every operation it observes
+type recordingHook struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+func (h *recordingHook) Before(ctx context.Context, op, query string, args []interface{}) context.Context {
+	return ctx
+}
+
+func (h *recordingHook) After(ctx context.Context, op, query string, args []interface{}, err error, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.errs == nil {
+		h.errs = make(map[string]error)
+	}
+	h.errs[op] = err
+}
+
+func TestTransactionPanicRollsBackAndRepanics(t *testing.T) {
+	d, mock := newMockDB(t)
+	hook := &recordingHook{}
+	d.Use(hook)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = d.Transaction(context.Background(), func(tx Transaction) error {
+			if _, err := tx.Exec(context.Background(), "INSERT INTO orders VALUES (1)"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	var panicErr *PanicError
+	require.ErrorAs(t, hook.errs["transaction"], &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+}
+
+func TestTransactionPanicAsError(t *testing.T) {
+	d, mock := newMockDB(t, WithPanicAsError())
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		panic(errors.New("boom"))
+	})
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.EqualError(t, panicErr.Value.(error), "boom")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionPanicRepanicsWhenRollbackFails(t *testing.T) {
+	d, mock := newMockDB(t)
+	hook := &recordingHook{}
+	d.Use(hook)
+	rbErr := errors.New("connection lost")
+	mock.ExpectBegin()
+	mock.ExpectRollback().WillReturnError(rbErr)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = d.Transaction(context.Background(), func(tx Transaction) error {
+			panic("boom")
+		})
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	err := hook.errs["transaction"]
+	assert.ErrorIs(t, err, rbErr)
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+}
+
+func TestTransactionPanicAsErrorKeepsBothErrorsWhenRollbackFails(t *testing.T) {
+	d, mock := newMockDB(t, WithPanicAsError())
+	rbErr := errors.New("connection lost")
+	mock.ExpectBegin()
+	mock.ExpectRollback().WillReturnError(rbErr)
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		panic("boom")
+	})
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "rollback", dbErr.Operation)
+	assert.ErrorIs(t, err, rbErr)
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+}
+
+func TestOperationSpans(t *testing.T) {
+	d, mock := newMockDB(t)
+	spans := recordSpans(t)
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id").WillReturnError(errors.New("boom"))
+
+	_, err := d.Exec(context.Background(), "UPDATE orders SET status = 'paid'")
+	require.NoError(t, err)
+	_, err = d.Query(context.Background(), "SELECT id FROM orders")
+	require.Error(t, err)
+
+	require.Len(t, *spans, 2)
+	assert.Equal(t, "db.exec", (*spans)[0].Name)
+	assert.NoError(t, (*spans)[0].Err)
+	assert.Equal(t, "db.query", (*spans)[1].Name)
+	assert.ErrorContains(t, (*spans)[1].Err, "boom")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// order is the value returned by the TransactionValue tests
+type order struct {
+	ID int64
+}
+
+func TestTransactionValueReturnsValueOnCommit(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(42, 1))
+	mock.ExpectCommit()
+
+	got, err := TransactionValue(context.Background(), d, func(tx Transaction) (*order, error) {
+		res, err := tx.Exec(context.Background(), "INSERT INTO orders (status) VALUES ('new')")
+		if err != nil {
+			return nil, err
+		}
+		return &order{ID: res.LastInsertId}, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &order{ID: 42}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionValueReturnsZeroOnRollback(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	errInvalid := errors.New("invalid order")
+
+	got, err := TransactionValue(context.Background(), d, func(tx Transaction) (order, error) {
+		return order{ID: 7}, errInvalid
+	})
+
+	assert.ErrorIs(t, err, errInvalid)
+	assert.Zero(t, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionValueReturnsZeroWhenCommitFails(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(errors.New("connection lost"))
+
+	got, err := TransactionValue(context.Background(), d, func(tx Transaction) (int, error) {
+		return 7, nil
+	})
+
+	assert.ErrorContains(t, err, "connection lost")
+	assert.Zero(t, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryEachIteratesEveryRow(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	var ids []int64
+	err := d.QueryEach(context.Background(), "SELECT id FROM orders", nil, func(r Row) error {
+		var id int64
+		if err := r.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryEachStopsAtCallbackError(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3)).
+		RowsWillBeClosed()
+	errStop := errors.New("stop")
+
+	calls := 0
+	err := d.QueryEach(context.Background(), "SELECT id FROM orders", nil, func(r Row) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 2, calls)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryEachReportsRowError(t *testing.T) {
+	d, mock := newMockDB(t)
+	errRow := errors.New("bad row")
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).RowError(1, errRow))
+
+	calls := 0
+	err := d.QueryEach(context.Background(), "SELECT id FROM orders", nil, func(r Row) error {
+		calls++
+		return nil
+	})
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "scan", dbErr.Operation)
+	assert.ErrorIs(t, err, errRow)
+	assert.Equal(t, 1, calls)
+}
+
+func TestQueryColumnScansIntsAndStrings(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(5))
+	mock.ExpectQuery("SELECT status, id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "id"}).AddRow("new", 3).AddRow("paid", 5))
+
+	var ids []int64
+	require.NoError(t, d.QueryColumn(context.Background(), &ids, "SELECT id FROM orders"))
+	assert.Equal(t, []int64{3, 5}, ids)
+
+	var statuses []string
+	require.NoError(t, d.QueryColumn(context.Background(), &statuses, "SELECT status, id FROM orders"))
+	assert.Equal(t, []string{"new", "paid"}, statuses)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryColumnReportsTypeMismatch(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paid"))
+
+	var ids []int64
+	err := d.QueryColumn(context.Background(), &ids, "SELECT status FROM orders")
+
+	assert.ErrorContains(t, err, `cannot scan column "status" into int64`)
+	assert.Empty(t, ids, "dest is left unchanged on error")
+}
+
+func TestQueryColumnRejectsNonSliceDest(t *testing.T) {
+	d, _ := newMockDB(t)
+	var id int64
+	err := d.QueryColumn(context.Background(), &id, "SELECT id FROM orders")
+	assert.ErrorContains(t, err, "pointer to a slice")
+}
+
+// noInsertIDResult is a driver result whose LastInsertId is unsupported,
+// as for an UPDATE on a table without an auto-increment column
+type noInsertIDResult struct {
+	rows int64
+}
+
+func (r noInsertIDResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by this driver")
+}
+
+func (r noInsertIDResult) RowsAffected() (int64, error) {
+	return r.rows, nil
+}
+
+func TestExecToleratesUnsupportedLastInsertId(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectExec("UPDATE orders").WillReturnResult(noInsertIDResult{rows: 2})
+
+	res, err := d.Exec(context.Background(), "UPDATE orders SET status = ? WHERE region = ?", "paid", "eu")
+
+	require.NoError(t, err)
+	assert.Equal(t, &Result{LastInsertId: 0, RowsAffected: 2}, res)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecFailsWhenRowsAffectedFails(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewErrorResult(errors.New("no row count")))
+
+	_, err := d.Exec(context.Background(), "UPDATE orders SET status = 'paid'")
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "rows_affected", dbErr.Operation)
+}
+
+// poolConfig returns a config with the given pool limits
+func poolConfig(maxOpen, maxIdle int, lifetime time.Duration) *config.Config {
+	cfg := &config.Config{}
+	cfg.Database.MaxOpenConns = maxOpen
+	cfg.Database.MaxIdleConns = maxIdle
+	cfg.Database.MaxLifetime = lifetime
+	return cfg
+}
+
+func TestReconfigureAppliesPoolLimits(t *testing.T) {
+	d, _ := newMockDB(t)
+	require.NoError(t, d.Reconfigure(poolConfig(4, 2, time.Minute)))
+	assert.Equal(t, 4, d.DB.Stats().MaxOpenConnections)
+
+	require.NoError(t, d.Reconfigure(poolConfig(8, 2, time.Minute)))
+	assert.Equal(t, 8, d.DB.Stats().MaxOpenConnections)
+}
+
+func TestReconfigureRejectsInvalidLimits(t *testing.T) {
+	d, _ := newMockDB(t)
+	require.NoError(t, d.Reconfigure(poolConfig(4, 2, time.Minute)))
+
+	for name, cfg := range map[string]*config.Config{
+		"maxOpenConns": poolConfig(0, 2, time.Minute),
+		"maxIdleConns": poolConfig(8, 0, time.Minute),
+		"maxLifetime":  poolConfig(8, 2, 0),
+	} {
+		err := d.Reconfigure(cfg)
+		assert.ErrorContains(t, err, name)
+		assert.Equal(t, 4, d.DB.Stats().MaxOpenConnections, "%s: live pool unchanged", name)
+	}
+}
+
+func TestTransactionRollbackWrapsOriginalError(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	errOutOfStock := errors.New("out of stock")
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		return errOutOfStock
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "transaction_rolled_back", dbErr.Operation)
+	assert.ErrorIs(t, err, errOutOfStock)
+}
+
+func TestTransactionFailedRollbackAndCommitAreDistinct(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectRollback().WillReturnError(errors.New("conn reset"))
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(errors.New("serialization failure"))
+
+	rollbackErr := d.Transaction(context.Background(), func(tx Transaction) error {
+		return errors.New("out of stock")
+	})
+	commitErr := d.Transaction(context.Background(), func(tx Transaction) error {
+		return nil
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	var dbErr *Error
+	require.ErrorAs(t, rollbackErr, &dbErr)
+	assert.Equal(t, "rollback", dbErr.Operation)
+	assert.ErrorContains(t, rollbackErr, "out of stock")
+	require.ErrorAs(t, commitErr, &dbErr)
+	assert.Equal(t, "commit", dbErr.Operation)
+}
+
+// fakeCollector records counter increments and histogram observations,
+// discarding everything else
+type fakeCollector struct {
+	metrics.Collector
+	mu           sync.Mutex
+	counters     map[string]float64
+	observations map[string][]float64
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{
+		Collector:    metrics.NewNop(),
+		counters:     make(map[string]float64),
+		observations: make(map[string][]float64),
+	}
+}
+
+func (c *fakeCollector) IncrementCounter(name string, value float64, labels metrics.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[name] += value
+}
+
+func (c *fakeCollector) ObserveHistogram(name string, value float64, labels metrics.Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observations[name] = append(c.observations[name], value)
+}
+
+func TestTransactionMetrics(t *testing.T) {
+	collector := newFakeCollector()
+	d, mock := newMockDB(t, WithCollector(collector))
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	commit := func(tx Transaction) error { return nil }
+	require.NoError(t, d.Transaction(context.Background(), commit))
+	require.Error(t, d.Transaction(context.Background(), func(tx Transaction) error {
+		return errors.New("out of stock")
+	}))
+	require.NoError(t, d.Transaction(context.Background(), commit))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 2.0, collector.counters[metricTransactionCommits])
+	assert.Equal(t, 1.0, collector.counters[metricTransactionRollbacks])
+	assert.Len(t, collector.observations[metricTransactionDuration], 3)
+}
+
+func TestTransactionBeginFailureRecordsNoMetrics(t *testing.T) {
+	collector := newFakeCollector()
+	d, mock := newMockDB(t, WithCollector(collector))
+	mock.ExpectBegin().WillReturnError(errors.New("too many connections"))
+
+	require.Error(t, d.Transaction(context.Background(), func(tx Transaction) error { return nil }))
+
+	assert.Empty(t, collector.counters)
+	assert.Empty(t, collector.observations)
+}
+
+func TestQueryRowMissingRowIsErrNotFound(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT status FROM orders").WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+
+	var status string
+	err := d.QueryRow(context.Background(), "SELECT status FROM orders WHERE id = ?", 7).Scan(&status)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "the driver error stays in the chain")
+	assert.True(t, IsNoRows(err))
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "scan", dbErr.Operation)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxQueryRowMissingRowIsErrNotFound(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+	mock.ExpectRollback()
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		var status string
+		return tx.QueryRow(context.Background(), "SELECT status FROM orders WHERE id = 1").Scan(&status)
+	})
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryRowFoundRowAndOtherErrors(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paid"))
+	mock.ExpectQuery("SELECT status FROM orders").
+		WillReturnError(errors.New("connection reset"))
+
+	var status string
+	require.NoError(t, d.QueryRow(context.Background(), "SELECT status FROM orders").Scan(&status))
+	assert.Equal(t, "paid", status)
+
+	err := d.QueryRow(context.Background(), "SELECT status FROM orders").Scan(&status)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.False(t, IsNoRows(err))
+}
+
+func TestIsNoRowsRecognizesBothSentinels(t *testing.T) {
+	assert.True(t, IsNoRows(sql.ErrNoRows))
+	assert.True(t, IsNoRows(ErrNotFound))
+	assert.True(t, IsNoRows(fmt.Errorf("load order: %w", sql.ErrNoRows)))
+	assert.False(t, IsNoRows(errors.New("other")))
+	assert.False(t, IsNoRows(nil))
+}
+
+// pingMockDB returns a db whose sqlmock checks pings against expectations
+func pingMockDB(t *testing.T) (*db, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	d := &db{DB: sqlDB}
+	d.config.Store(&config.Config{})
+	return d, mock
+}
+
+func TestPingReturnsAtCallerDeadline(t *testing.T) {
+	d, mock := pingMockDB(t)
+	mock.ExpectPing().WillDelayFor(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := d.Ping(ctx)
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "ping", dbErr.Operation)
+}
+
+func TestHealthCheckReturnsAtCallerDeadline(t *testing.T) {
+	d, mock := pingMockDB(t)
+	mock.ExpectPing().WillDelayFor(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	assert.Error(t, d.HealthCheck(ctx))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestPingWithoutDeadlineWaitsForSlowPing(t *testing.T) {
+	d, mock := pingMockDB(t)
+	mock.ExpectPing().WillDelayFor(20 * time.Millisecond)
+
+	assert.NoError(t, d.Ping(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPingReportsDriverError(t *testing.T) {
+	d, mock := pingMockDB(t)
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	err := d.Ping(context.Background())
+
+	assert.ErrorContains(t, err, "ping: connection refused")
+}
+
+func TestExecRowsSucceedsWithoutLastInsertId(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectExec("UPDATE orders").WithArgs("paid", "eu").WillReturnResult(noInsertIDResult{rows: 4})
+
+	affected, err := d.ExecRows(context.Background(), "UPDATE orders SET status = ? WHERE region = ?", "paid", "eu")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, affected)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxExecRowsSucceedsWithoutLastInsertId(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM carts").WillReturnResult(noInsertIDResult{rows: 1})
+	mock.ExpectCommit()
+
+	var affected int64
+	err := d.Transaction(context.Background(), func(tx Transaction) (err error) {
+		affected, err = tx.ExecRows(context.Background(), "DELETE FROM carts WHERE id = 1")
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, affected)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecRowsReportsRowsAffectedFailure(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewErrorResult(errors.New("no row count")))
+
+	_, err := d.ExecRows(context.Background(), "UPDATE orders SET status = 'paid'")
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "rows_affected", dbErr.Operation)
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrEmptyIn is returned when an IN-clause slice argument is empty and
+// ExpandInOptions.EmptyAsError is set
+var ErrEmptyIn = errors.New("empty slice argument for IN clause")
+
+// ExpandInOptions controls IN-clause expansion
+type ExpandInOptions struct {
+	// EmptyAsError returns ErrEmptyIn for an empty slice instead of
+	// rewriting the placeholder to NULL, which matches no rows
+	EmptyAsError bool
+}
+
+// ExpandIn expands each slice argument's placeholder into one placeholder
+// per element, so `WHERE id IN (?)` with []int64{1, 2, 3} becomes
+// `WHERE id IN (?,?,?)` with the flattened args. Scalar placeholders are
+// left intact and an empty slice is rewritten to `IN (NULL)`.
+func ExpandIn(query string, args ...interface{}) (string, []interface{}, error) {
+	return ExpandInWith(ExpandInOptions{}, query, args...)
+}
+
+// In is ExpandIn with sqlx.In semantics: an empty slice argument is an
+// error wrapping ErrEmptyIn rather than matching no rows
+func In(query string, args ...interface{}) (string, []interface{}, error) {
+	return ExpandInWith(ExpandInOptions{EmptyAsError: true}, query, args...)
+}
+
+// ExpandInWith is ExpandIn with explicit options
+func ExpandInWith(opts ExpandInOptions, query string, args ...interface{}) (string, []interface{}, error) {
+	var sb strings.Builder
+	sb.Grow(len(query))
+	expanded := make([]interface{}, 0, len(args))
+
+	argIndex := 0
+	var quote rune
+	escaped := false
+	for _, ch := range query {
+		// Placeholders inside string literals or quoted identifiers are
+		// text. A backslash escapes the next character of a string literal;
+		// a doubled quote closes and reopens the literal, which is the same.
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\' && quote != '`':
+				escaped = true
+			case ch == quote:
+				quote = 0
+			}
+			sb.WriteRune(ch)
+			continue
+		}
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+			sb.WriteRune(ch)
+			continue
+		case '?':
+		default:
+			sb.WriteRune(ch)
+			continue
+		}
+
+		if argIndex >= len(args) {
+			return "", nil, fmt.Errorf("expand in: query has more placeholders than the %d args", len(args))
+		}
+		arg := args[argIndex]
+		argIndex++
+
+		v := reflect.ValueOf(arg)
+		if !isExpandable(arg, v) {
+			sb.WriteRune('?')
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		if v.Len() == 0 {
+			if opts.EmptyAsError {
+				return "", nil, fmt.Errorf("expand in: argument %d: %w", argIndex, ErrEmptyIn)
+			}
+			sb.WriteString("NULL")
+			continue
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				sb.WriteRune(',')
+			}
+			sb.WriteRune('?')
+			expanded = append(expanded, v.Index(i).Interface())
+		}
+	}
+
+	if argIndex != len(args) {
+		return "", nil, fmt.Errorf("expand in: query has %d placeholders but %d args", argIndex, len(args))
+	}
+
+	return sb.String(), expanded, nil
+}
+
+// isExpandable reports whether an argument is a slice to expand. Byte
+// slices are single values (BLOBs) and, as in sqlx, so are slices that
+// implement driver.Valuer and encode themselves.
+func isExpandable(arg interface{}, v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
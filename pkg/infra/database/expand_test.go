@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandInMixedScalarAndSliceArgs(t *testing.T) {
+	query, args, err := ExpandIn(
+		"SELECT id FROM orders WHERE status = ? AND id IN (?) AND note <> '?' AND region IN (?)",
+		"paid", []int64{1, 2, 3}, []string{"eu"},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM orders WHERE status = ? AND id IN (?,?,?) AND note <> '?' AND region IN (?)", query)
+	assert.Equal(t, []interface{}{"paid", int64(1), int64(2), int64(3), "eu"}, args)
+}
+
+func TestExpandInKeepsByteSlicesWhole(t *testing.T) {
+	query, args, err := ExpandIn("UPDATE files SET data = ? WHERE id = ?", []byte("blob"), 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE files SET data = ? WHERE id = ?", query)
+	assert.Equal(t, []interface{}{[]byte("blob"), 7}, args)
+}
+
+func TestExpandInSkipsEscapedQuotesInLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  string
+	}{
+		{
+			name:  "backslash-escaped quote",
+			query: `SELECT id FROM orders WHERE note = 'it\'s ?' AND id IN (?)`,
+			args:  []interface{}{[]int64{1, 2}},
+			want:  `SELECT id FROM orders WHERE note = 'it\'s ?' AND id IN (?,?)`,
+		},
+		{
+			name:  "doubled quote",
+			query: `SELECT id FROM orders WHERE note = 'it''s ?' AND id IN (?)`,
+			args:  []interface{}{[]int64{1, 2}},
+			want:  `SELECT id FROM orders WHERE note = 'it''s ?' AND id IN (?,?)`,
+		},
+		{
+			name:  "escaped backslash before the closing quote",
+			query: `SELECT id FROM orders WHERE path = 'dir\\' AND id IN (?)`,
+			args:  []interface{}{[]int64{1, 2}},
+			want:  `SELECT id FROM orders WHERE path = 'dir\\' AND id IN (?,?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := ExpandIn(tt.query, tt.args...)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, []interface{}{int64(1), int64(2)}, args)
+		})
+	}
+}
+
+// tagList is a slice that stores itself as one comma-separated value
+type tagList []string
+
+func (l tagList) Value() (driver.Value, error) {
+	return strings.Join(l, ","), nil
+}
+
+func TestExpandInBindsValuerSlicesWhole(t *testing.T) {
+	tags := tagList{"gift", "urgent"}
+	for name, expand := range map[string]func(string, ...interface{}) (string, []interface{}, error){
+		"ExpandIn": ExpandIn,
+		"In":       In,
+	} {
+		query, args, err := expand("UPDATE orders SET tags = ? WHERE id IN (?)", tags, []int64{1, 2})
+
+		require.NoError(t, err, name)
+		assert.Equal(t, "UPDATE orders SET tags = ? WHERE id IN (?,?)", query, name)
+		assert.Equal(t, []interface{}{tags, int64(1), int64(2)}, args, name)
+	}
+}
+
+func TestExpandInEmptySlice(t *testing.T) {
+	query, args, err := ExpandIn("SELECT id FROM orders WHERE id IN (?) AND status = ?", []int64{}, "paid")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM orders WHERE id IN (NULL) AND status = ?", query)
+	assert.Equal(t, []interface{}{"paid"}, args)
+
+	_, _, err = In("SELECT id FROM orders WHERE id IN (?)", []int64{})
+	assert.ErrorIs(t, err, ErrEmptyIn)
+}
+
+func TestExpandInArgCountMismatch(t *testing.T) {
+	_, _, err := ExpandIn("SELECT id FROM orders WHERE id IN (?) AND status = ?", []int64{1})
+	assert.ErrorContains(t, err, "more placeholders")
+
+	_, _, err = ExpandIn("SELECT id FROM orders WHERE id = ?", 1, 2)
+	assert.ErrorContains(t, err, "1 placeholders but 2 args")
+}
+
+func TestInSingleSlice(t *testing.T) {
+	query, args, err := In("DELETE FROM orders WHERE id IN (?)", []int{4, 5})
+
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM orders WHERE id IN (?,?)", query)
+	assert.Equal(t, []interface{}{4, 5}, args)
+}
+
+func TestInMixedScalarAndSliceArgs(t *testing.T) {
+	query, args, err := In(
+		"UPDATE orders SET status = ? WHERE region = ? AND id IN (?)",
+		"cancelled", "eu", [2]int64{8, 9},
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE orders SET status = ? WHERE region = ? AND id IN (?,?)", query)
+	assert.Equal(t, []interface{}{"cancelled", "eu", int64(8), int64(9)}, args)
+}
+
+func TestInEmptySliceNamesArgument(t *testing.T) {
+	_, _, err := In("UPDATE orders SET status = ? WHERE id IN (?)", "paid", []string{})
+
+	assert.ErrorIs(t, err, ErrEmptyIn)
+	assert.ErrorContains(t, err, "argument 2")
+}
+
+func TestInExpandsBulkDeleteAndUpdate(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectExec(`^DELETE FROM carts WHERE id IN \(\?,\?,\?\)$`).
+		WithArgs(1, 2, 3).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`^UPDATE orders SET status = \? WHERE id IN \(\?,\?\)$`).
+		WithArgs("shipped", int64(10), int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	query, args, err := In("DELETE FROM carts WHERE id IN (?)", []int{1, 2, 3})
+	require.NoError(t, err)
+	deleted, err := d.ExecRows(context.Background(), query, args...)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, deleted)
+
+	query, args, err = In("UPDATE orders SET status = ? WHERE id IN (?)", "shipped", []int64{10, 11})
+	require.NoError(t, err)
+	updated, err := d.ExecRows(context.Background(), query, args...)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, updated)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
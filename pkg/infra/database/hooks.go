@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Use adds a hook that observes every database operation. Hooks run in the
+// order they were added for Before and in reverse order for After.
+func (d *db) Use(hook QueryHook) {
+	d.hooksMu.Lock()
+	defer d.hooksMu.Unlock()
+
+	hooks := make([]QueryHook, 0, len(d.hooks)+1)
+	hooks = append(hooks, d.hooks...)
+	d.hooks = append(hooks, hook)
+}
+
+// runHooks calls Before on each hook and returns the derived context and a
+// function that calls After once the operation finishes, error or not
+func (d *db) runHooks(ctx context.Context, op, query string, args []interface{}) (context.Context, func(err error)) {
+	d.hooksMu.RLock()
+	hooks := d.hooks
+	d.hooksMu.RUnlock()
+
+	if len(hooks) == 0 {
+		return ctx, func(error) {}
+	}
+
+	start := time.Now()
+	for _, hook := range hooks {
+		ctx = hook.Before(ctx, op, query, args)
+	}
+
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hooks[i].After(ctx, op, query, args, err, duration)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observation is an operation as seen by a hook's After
+type observation struct {
+	op    string
+	query string
+	err   error
+}
+
+// observingHook records each operation it observes under name in calls
+type observingHook struct {
+	name  string
+	calls *[]string
+	seen  []observation
+}
+
+type hookKey string
+
+func (h *observingHook) Before(ctx context.Context, op, query string, args []interface{}) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, hookKey(h.name), true)
+}
+
+func (h *observingHook) After(ctx context.Context, op, query string, args []interface{}, err error, duration time.Duration) {
+	*h.calls = append(*h.calls, "after "+h.name)
+	if ctx.Value(hookKey(h.name)) == nil {
+		panic("After did not get the context returned by Before")
+	}
+	h.seen = append(h.seen, observation{op: op, query: query, err: err})
+}
+
+func TestHookObservesSuccessAndFailure(t *testing.T) {
+	d, mock := newMockDB(t)
+	var calls []string
+	hook := &observingHook{name: "a", calls: &calls}
+	d.Use(hook)
+	boom := errors.New("boom")
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id FROM orders").WillReturnError(boom)
+
+	_, err := d.Exec(context.Background(), "INSERT INTO orders VALUES (1)")
+	require.NoError(t, err)
+	_, err = d.Query(context.Background(), "SELECT id FROM orders")
+	require.Error(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, hook.seen, 2)
+	assert.Equal(t, observation{op: "exec", query: "INSERT INTO orders VALUES (1)"}, hook.seen[0])
+	assert.Equal(t, "query", hook.seen[1].op)
+	assert.Equal(t, "SELECT id FROM orders", hook.seen[1].query)
+	assert.ErrorIs(t, hook.seen[1].err, boom)
+}
+
+func TestHooksCompose(t *testing.T) {
+	d, mock := newMockDB(t)
+	var calls []string
+	d.Use(&observingHook{name: "a", calls: &calls})
+	d.Use(&observingHook{name: "b", calls: &calls})
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		_, err := tx.Exec(context.Background(), "UPDATE orders SET status = 'paid'")
+		return err
+	})
+
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{
+		"before a", "before b", // transaction
+		"before a", "before b", "after b", "after a", // tx.exec
+		"after b", "after a", // transaction
+	}, calls)
+}
+
+func TestQueryRowFinishesOnScan(t *testing.T) {
+	d, mock := newMockDB(t)
+	var calls []string
+	hook := &observingHook{name: "a", calls: &calls}
+	d.Use(hook)
+	spans := recordSpans(t)
+	boom := errors.New("boom")
+	mock.ExpectQuery("SELECT status FROM orders").WillReturnError(boom)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM orders").WillReturnError(boom)
+	mock.ExpectRollback()
+
+	var status string
+	r := d.QueryRow(context.Background(), "SELECT status FROM orders WHERE id = 1")
+	assert.Empty(t, hook.seen, "nothing is reported before Scan")
+	require.ErrorIs(t, r.Scan(&status), boom)
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		return tx.QueryRow(context.Background(), "SELECT status FROM orders WHERE id = 1").Scan(&status)
+	})
+	require.ErrorIs(t, err, boom)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, hook.seen, 3)
+	assert.Equal(t, "query_row", hook.seen[0].op)
+	assert.ErrorIs(t, hook.seen[0].err, boom)
+	assert.Equal(t, "tx.query_row", hook.seen[1].op)
+	assert.ErrorIs(t, hook.seen[1].err, boom)
+	spanErrs := make(map[string]error)
+	for _, s := range *spans {
+		spanErrs[s.Name] = s.Err
+	}
+	assert.ErrorIs(t, spanErrs["db.query_row"], boom)
+	assert.ErrorIs(t, spanErrs["db.tx.query_row"], boom)
+}
+
+func TestQueryRowAcquiresFromPool(t *testing.T) {
+	d, _ := newMockDB(t)
+	var calls []string
+	hook := &observingHook{name: "a", calls: &calls}
+	d.Use(hook)
+	d.settings().Database.AcquireTimeout = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var status string
+	err := d.QueryRow(ctx, "SELECT status FROM orders WHERE id = 1").Scan(&status)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "acquire", dbErr.Operation)
+	require.Len(t, hook.seen, 1)
+	assert.ErrorIs(t, hook.seen[0].err, context.Canceled)
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"order-system/pkg/platform/metrics"
+)
+
+// Metric names recorded when a collector is wired
+const (
+	metricPoolWaitTimeouts     = "db_pool_wait_timeouts_total"
+	metricTransactionDuration  = "db_transaction_duration_seconds"
+	metricTransactionCommits   = "db_transaction_commit_total"
+	metricTransactionRollbacks = "db_transaction_rollback_total"
+)
+
+// Option configures a Database
+type Option func(*db)
+
+// WithCollector records database metrics to c
+func WithCollector(c metrics.Collector) Option {
+	return func(d *db) {
+		d.collector = c
+	}
+}
+
+// WithPanicAsError makes Transaction return a *PanicError, wrapped in an
+// *Error, when the transaction function panics. By default the panic is
+// resumed once the transaction has been rolled back.
+func WithPanicAsError() Option {
+	return func(d *db) {
+		d.panicAsError = true
+	}
+}
+
+// registerMetrics registers the database metrics with the collector.
+// Metrics that are already registered are reused.
+func (d *db) registerMetrics() {
+	if d.collector == nil {
+		return
+	}
+	_ = d.collector.Register(metricPoolWaitTimeouts, metrics.Counter, "Calls that timed out waiting for a pooled connection")
+	_ = d.collector.Register(metricTransactionDuration, metrics.Histogram, "Transaction duration in seconds, including commit or rollback")
+	_ = d.collector.Register(metricTransactionCommits, metrics.Counter, "Committed transactions")
+	_ = d.collector.Register(metricTransactionRollbacks, metrics.Counter, "Rolled back transactions")
+}
+
+// incrementCounter increments a database counter when a collector is wired
+func (d *db) incrementCounter(name string, labels metrics.Labels) {
+	if d.collector != nil {
+		d.collector.IncrementCounter(name, 1, labels)
+	}
+}
+
+// observeHistogram records a database observation when a collector is wired
+func (d *db) observeHistogram(name string, value float64) {
+	if d.collector != nil {
+		d.collector.ObserveHistogram(name, value, nil)
+	}
+}
+
+// queryer is the subset of *sql.DB and *sql.Conn used to run statements
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// acquire returns a connection to run a statement on and a function that
+// releases it. With an AcquireTimeout configured, the connection is taken
+// from the pool up front so that waiting for the pool is distinguished from
+// executing the statement: running out of time while waiting returns an
+// *Error with CodePoolExhausted.
+func (d *db) acquire(ctx context.Context, query string) (queryer, func(), error) {
+	conn, err := d.conn(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conn == nil {
+		return d.DB, func() {}, nil
+	}
+	return conn, func() { conn.Close() }, nil
+}
+
+// begin starts a transaction, taking its connection from the pool under the
+// same AcquireTimeout as acquire. The returned function releases the
+// connection once the transaction has finished.
+func (d *db) begin(ctx context.Context) (*sql.Tx, func(), error) {
+	conn, err := d.conn(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tx *sql.Tx
+	release := func() {}
+	if conn == nil {
+		tx, err = d.DB.BeginTx(ctx, nil)
+	} else {
+		release = func() { conn.Close() }
+		tx, err = conn.BeginTx(ctx, nil)
+	}
+	if err != nil {
+		release()
+		return nil, nil, &Error{
+			Operation: "begin_transaction",
+			Err:       err,
+		}
+	}
+	return tx, release, nil
+}
+
+// conn takes a connection from the pool within the AcquireTimeout. It
+// returns nil without an AcquireTimeout, leaving the pool to the caller's
+// context.
+func (d *db) conn(ctx context.Context, query string) (*sql.Conn, error) {
+	timeout := d.settings().Database.AcquireTimeout
+	if timeout <= 0 {
+		return nil, nil
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := d.DB.Conn(acquireCtx)
+	if err != nil {
+		// Only attribute the failure to the pool if the caller's context is
+		// still live; otherwise the caller gave up first
+		if acquireCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			d.incrementCounter(metricPoolWaitTimeouts, nil)
+			return nil, &Error{
+				Operation: "acquire",
+				Code:      CodePoolExhausted,
+				Query:     query,
+				Err:       fmt.Errorf("no connection available within %s: %w", timeout, err),
+			}
+		}
+		return nil, &Error{
+			Operation: "acquire",
+			Query:     query,
+			Err:       err,
+		}
+	}
+	return conn, nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/metrics"
+)
+
+func TestAcquireTimeoutReportsPoolExhausted(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, err := metrics.New(cfg)
+	require.NoError(t, err)
+
+	d, mock := newMockDB(t, WithCollector(collector))
+	d.settings().Database.AcquireTimeout = 20 * time.Millisecond
+	d.DB.SetMaxOpenConns(1)
+	mock.ExpectQuery("SELECT SLEEP").
+		WillDelayFor(200 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"slept"}).AddRow(1))
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := d.Query(context.Background(), "SELECT SLEEP(1)")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return d.DB.Stats().InUse == 1 }, time.Second, time.Millisecond)
+
+	start := time.Now()
+	_, err = d.Query(context.Background(), "SELECT SLEEP(1)")
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, CodePoolExhausted, dbErr.Code)
+	assert.Less(t, time.Since(start), 150*time.Millisecond, "gave up at the acquire timeout")
+	assert.Equal(t, 1.0, collector.GetCounter(metricPoolWaitTimeouts, nil))
+
+	require.NoError(t, <-first)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactionBeginHonoursAcquireTimeout(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	collector, err := metrics.New(cfg)
+	require.NoError(t, err)
+
+	d, mock := newMockDB(t, WithCollector(collector))
+	d.settings().Database.AcquireTimeout = 20 * time.Millisecond
+	d.DB.SetMaxOpenConns(1)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	held, done := make(chan struct{}), make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		first <- d.Transaction(context.Background(), func(Transaction) error {
+			close(held)
+			<-done
+			return nil
+		})
+	}()
+	<-held
+
+	start := time.Now()
+	err = d.Transaction(context.Background(), func(Transaction) error { return nil })
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, CodePoolExhausted, dbErr.Code)
+	assert.Less(t, time.Since(start), 150*time.Millisecond, "gave up at the acquire timeout")
+	assert.Equal(t, 1.0, collector.GetCounter(metricPoolWaitTimeouts, nil))
+
+	close(done)
+	require.NoError(t, <-first)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Zero(t, d.DB.Stats().InUse, "the transaction released its connection")
+}
+
+func TestAcquireAttributesCallerCancelToCaller(t *testing.T) {
+	d, _ := newMockDB(t)
+	d.settings().Database.AcquireTimeout = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := d.Query(ctx, "SELECT 1")
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Empty(t, dbErr.Code)
+	assert.ErrorIs(t, err, context.Canceled)
+}
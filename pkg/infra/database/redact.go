@@ -0,0 +1,47 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// redactedSecret replaces credentials in error messages
+const redactedSecret = "[REDACTED]"
+
+// redactedError hides the DSN password from the message of the error it
+// wraps
+type redactedError struct {
+	err         error
+	credentials string // "user:password@" as it appears in the DSN
+	replacement string // "user:[REDACTED]@"
+}
+
+// redactDSN wraps err so its message never shows the password of dsn. Only
+// the password field, "user:password@", is replaced, so a short or common
+// password can't mangle unrelated text.
+func redactDSN(err error, dsn string) error {
+	if err == nil {
+		return err
+	}
+	cfg, parseErr := mysql.ParseDSN(dsn)
+	if parseErr != nil || cfg.Passwd == "" {
+		return err
+	}
+	return &redactedError{
+		err:         err,
+		credentials: cfg.User + ":" + cfg.Passwd + "@",
+		replacement: cfg.User + ":" + redactedSecret + "@",
+	}
+}
+
+// Error implements the error interface
+func (e *redactedError) Error() string {
+	return strings.ReplaceAll(e.err.Error(), e.credentials, e.replacement)
+}
+
+// Unwrap returns the underlying error so errors.Is and errors.As still
+// match it
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+func TestRedactDSNHidesOnlyThePasswordField(t *testing.T) {
+	dsn := "app:s3cret-pass@tcp(db:3306)/orders"
+	cause := errors.New("dial failed")
+	err := redactDSN(fmt.Errorf("open %s: %w", dsn, cause), dsn)
+
+	assert.Equal(t, "open app:[REDACTED]@tcp(db:3306)/orders: dial failed", err.Error())
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestRedactDSNLeavesShortPasswordTextAlone(t *testing.T) {
+	dsn := "app:db@tcp(db:3306)/db"
+	err := redactDSN(fmt.Errorf("connect to db at %s failed", dsn), dsn)
+
+	assert.Equal(t, "connect to db at app:[REDACTED]@tcp(db:3306)/db failed", err.Error())
+}
+
+func TestRedactDSNWithoutPassword(t *testing.T) {
+	cause := errors.New("refused")
+	assert.Same(t, cause, redactDSN(cause, "app@tcp(db:3306)/orders"))
+	assert.Nil(t, redactDSN(nil, "app:x@tcp(db:3306)/orders"))
+}
+
+// unreachableConfig returns a config pointing at a port nothing listens on
+func unreachableConfig(t *testing.T) *config.Config {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	require.NoError(t, ln.Close())
+
+	cfg := &config.Config{}
+	cfg.Database.Host = "127.0.0.1"
+	cfg.Database.Port = port
+	cfg.Database.User = "app"
+	cfg.Database.Password = "s3cret-pass"
+	cfg.Database.Database = "orders"
+	return cfg
+}
+
+func TestNewPingErrorHidesPassword(t *testing.T) {
+	_, err := New(unreachableConfig(t))
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "ping", dbErr.Operation)
+	assert.NotContains(t, err.Error(), "s3cret-pass")
+}
+
+func TestNewOpenErrorHidesPassword(t *testing.T) {
+	cfg := unreachableConfig(t)
+	cfg.Database.Database = "orders?timeout=soon"
+
+	_, err := New(cfg)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "open", dbErr.Operation)
+	assert.NotContains(t, err.Error(), "s3cret-pass")
+}
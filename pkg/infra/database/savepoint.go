@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"order-system/pkg/platform/trace"
+)
+
+// maxSavepointName is MySQL's identifier length limit
+const maxSavepointName = 64
+
+// Savepoint implements Transaction.Savepoint
+func (t *transaction) Savepoint(ctx context.Context, name string) error {
+	return t.savepointExec(ctx, "savepoint", "SAVEPOINT ", name)
+}
+
+// RollbackTo implements Transaction.RollbackTo
+func (t *transaction) RollbackTo(ctx context.Context, name string) error {
+	return t.savepointExec(ctx, "rollback_to_savepoint", "ROLLBACK TO SAVEPOINT ", name)
+}
+
+// ReleaseSavepoint implements Transaction.ReleaseSavepoint
+func (t *transaction) ReleaseSavepoint(ctx context.Context, name string) error {
+	return t.savepointExec(ctx, "release_savepoint", "RELEASE SAVEPOINT ", name)
+}
+
+// savepointExec validates name and runs statement with it appended. The
+// name can't be a bound parameter, so validation is what keeps it safe.
+func (t *transaction) savepointExec(ctx context.Context, op, statement, name string) (err error) {
+	if !validSavepointName(name) {
+		return &Error{
+			Operation: op,
+			Err:       fmt.Errorf("invalid savepoint name %q", name),
+		}
+	}
+
+	query := statement + name
+	ctx, finish := trace.StartSpan(ctx, "db.tx."+op)
+	defer func() { finish(err) }()
+	ctx, after := t.db.runHooks(ctx, "tx."+op, query, nil)
+	defer func() { after(err) }()
+
+	if _, err := t.Tx.ExecContext(ctx, query); err != nil {
+		return &Error{
+			Operation: op,
+			Query:     query,
+			Err:       err,
+		}
+	}
+	return nil
+}
+
+// validSavepointName reports whether name matches [a-zA-Z_][a-zA-Z0-9_]*
+// and fits in a MySQL identifier
+func validSavepointName(name string) bool {
+	if name == "" || len(name) > maxSavepointName {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		b := name[i]
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b == '_':
+		case b >= '0' && b <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavepointStatements(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SAVEPOINT before_items")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO order_items").WillReturnError(errors.New("duplicate"))
+	mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT before_items")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("RELEASE SAVEPOINT before_items")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		ctx := context.Background()
+		if err := tx.Savepoint(ctx, "before_items"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO order_items VALUES (1)"); err != nil {
+			if err := tx.RollbackTo(ctx, "before_items"); err != nil {
+				return err
+			}
+		}
+		return tx.ReleaseSavepoint(ctx, "before_items")
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSavepointRejectsInvalidNames(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	names := []string{"", "1st", "sp; DROP TABLE orders", "sp-1", "`sp`", strings.Repeat("s", maxSavepointName+1)}
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		ctx := context.Background()
+		for _, name := range names {
+			for _, call := range []func(context.Context, string) error{tx.Savepoint, tx.RollbackTo, tx.ReleaseSavepoint} {
+				err := call(ctx, name)
+				var dbErr *Error
+				if assert.ErrorAs(t, err, &dbErr, "%q", name) {
+					assert.Contains(t, dbErr.Error(), "invalid savepoint name")
+				}
+			}
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet(), "no statement was issued")
+}
+
+func TestSavepointReportsExecError(t *testing.T) {
+	d, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("ROLLBACK TO SAVEPOINT missing")).WillReturnError(errors.New("SAVEPOINT missing does not exist"))
+	mock.ExpectRollback()
+
+	err := d.Transaction(context.Background(), func(tx Transaction) error {
+		return tx.RollbackTo(context.Background(), "missing")
+	})
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.ErrorContains(t, err, "rollback_to_savepoint: ROLLBACK TO SAVEPOINT missing")
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"order-system/pkg/platform/logger"
+)
+
+// WithLogger logs slow queries and, when enabled, their plans and bound
+// arguments to log
+func WithLogger(log logger.Logger) Option {
+	return func(d *db) {
+		d.log = log.WithComponent("database")
+	}
+}
+
+// WithArgRedactor masks bound arguments before they are logged
+func WithArgRedactor(redact ArgRedactor) Option {
+	return func(d *db) {
+		d.redact = redact
+	}
+}
+
+// WithSlowLog writes slow queries to w, one JSON object per line, instead
+// of to the logger
+func WithSlowLog(w io.Writer) Option {
+	return func(d *db) {
+		d.slowLog = &slowLog{w: w}
+	}
+}
+
+// slowLog serializes slow-query entries written to a dedicated writer
+type slowLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// slowLogEntry is a slow query as written by WithSlowLog
+type slowLogEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Query      string    `json:"query"`
+	DurationMS float64   `json:"duration_ms"`
+	Rows       int64     `json:"rows"`
+}
+
+// write appends entry to the slow log
+func (s *slowLog) write(entry slowLogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// observeQuery logs the statement and its arguments when argument logging
+// is on, and reports statements that ran longer than the slow-query
+// threshold, followed by their EXPLAIN plan if enabled and it is a SELECT.
+// rows points at the number of rows returned or affected once the
+// statement has finished.
+func (d *db) observeQuery(ctx context.Context, conn queryer, query string, args []interface{}, start time.Time, rows *int64) {
+	if d.log == nil && d.slowLog == nil {
+		return
+	}
+
+	duration := time.Since(start)
+	if d.log != nil && d.settings().Database.LogArgs {
+		d.log.Debug(ctx, "query",
+			logger.Field{Key: "query", Value: query},
+			logger.Field{Key: "args", Value: d.loggedArgs(query, args)},
+			logger.Field{Key: "duration", Value: duration.String()},
+		)
+	}
+
+	threshold := d.settings().Database.SlowQueryThreshold
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+
+	if d.slowLog != nil {
+		err := d.slowLog.write(slowLogEntry{
+			Timestamp:  start,
+			Query:      query,
+			DurationMS: float64(duration) / float64(time.Millisecond),
+			Rows:       *rows,
+		})
+		if err != nil && d.log != nil {
+			d.log.Error(ctx, "failed to write slow query log", err)
+		}
+	} else {
+		d.log.Warn(ctx, "slow query",
+			logger.Field{Key: "query", Value: query},
+			logger.Field{Key: "duration", Value: duration.String()},
+			logger.Field{Key: "rows", Value: *rows},
+		)
+	}
+
+	if d.log != nil && d.settings().Database.ExplainSlowQueries && isSelect(query) {
+		d.explain(ctx, conn, query, args)
+	}
+}
+
+// loggedArgs returns args as they should be logged for query
+func (d *db) loggedArgs(query string, args []interface{}) []interface{} {
+	if d.redact == nil {
+		return args
+	}
+	return d.redact(query, args)
+}
+
+// explain runs EXPLAIN for a query and logs the plan rows at debug level
+func (d *db) explain(ctx context.Context, conn queryer, query string, args []interface{}) {
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		d.log.Debug(ctx, "explain failed",
+			logger.Field{Key: "query", Value: query},
+			logger.Field{Key: "error", Value: err.Error()},
+		)
+		return
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return
+	}
+
+	var plan []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		targets := make([]interface{}, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		plan = append(plan, row)
+	}
+
+	d.log.Debug(ctx, "query plan",
+		logger.Field{Key: "query", Value: query},
+		logger.Field{Key: "plan", Value: plan},
+	)
+}
+
+// isSelect reports whether a statement is a SELECT
+func isSelect(query string) bool {
+	trimmed := strings.TrimSpace(query)
+	return len(trimmed) >= 6 && strings.EqualFold(trimmed[:6], "SELECT")
+}
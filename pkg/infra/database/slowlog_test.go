@@ -0,0 +1,242 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/logger"
+)
+
+// bufferLogger returns a debug-level logger writing JSON lines to the
+// returned buffer
+func bufferLogger(t *testing.T) (logger.Logger, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv(logger.LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "debug"
+	cfg.Logger.Output = "stdout"
+
+	buf := &bytes.Buffer{}
+	l, err := logger.New(cfg,
+		logger.WithLevelOutput(logger.Debug, buf),
+		logger.WithLevelOutput(logger.Info, buf),
+		logger.WithLevelOutput(logger.Warn, buf),
+		logger.WithLevelOutput(logger.Error, buf),
+	)
+	require.NoError(t, err)
+	return l, buf
+}
+
+// loggedEntries decodes each JSON line in buf
+func loggedEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var decoded []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m))
+		decoded = append(decoded, m)
+	}
+	return decoded
+}
+
+// loggedMessages returns the msg of each entry in buf
+func loggedMessages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var msgs []string
+	for _, e := range loggedEntries(t, buf) {
+		msgs = append(msgs, e["msg"].(string))
+	}
+	return msgs
+}
+
+// explainDB returns a db that treats every statement as slow and explains
+// slow SELECTs
+func explainDB(t *testing.T) (*db, sqlmock.Sqlmock, *bytes.Buffer) {
+	t.Helper()
+	log, buf := bufferLogger(t)
+	d, mock := newMockDB(t, WithLogger(log))
+	d.settings().Database.SlowQueryThreshold = time.Nanosecond
+	d.settings().Database.ExplainSlowQueries = true
+	return d, mock, buf
+}
+
+func TestExplainRunsForSlowSelect(t *testing.T) {
+	d, mock, buf := explainDB(t)
+	mock.ExpectQuery("^SELECT id FROM orders WHERE status").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("^EXPLAIN SELECT id FROM orders WHERE status").
+		WithArgs("paid").
+		WillReturnRows(sqlmock.NewRows([]string{"type", "rows"}).AddRow("ALL", 42))
+
+	_, err := d.Query(context.Background(), "SELECT id FROM orders WHERE status = ?", "paid")
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"slow query", "query plan"}, loggedMessages(t, buf))
+	plan := loggedEntries(t, buf)[1]["fields"].(map[string]interface{})["plan"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"type": "ALL", "rows": 42.0}}, plan)
+}
+
+func TestSlowQueryRowLoggedOnScan(t *testing.T) {
+	d, mock, buf := explainDB(t)
+	mock.ExpectQuery("^SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paid"))
+	mock.ExpectQuery("^EXPLAIN SELECT status FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"type", "rows"}).AddRow("const", 1))
+
+	var status string
+	require.NoError(t, d.QueryRow(context.Background(), "SELECT status FROM orders WHERE id = 1").Scan(&status))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"slow query", "query plan"}, loggedMessages(t, buf))
+	assert.Equal(t, 1.0, loggedEntries(t, buf)[0]["fields"].(map[string]interface{})["rows"])
+}
+
+func TestExplainSkippedForInsert(t *testing.T) {
+	d, mock, buf := explainDB(t)
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err := d.Exec(context.Background(), "INSERT INTO orders (status) VALUES (?)", "paid")
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"slow query"}, loggedMessages(t, buf), "no EXPLAIN attempted")
+}
+
+func TestExplainOffByDefault(t *testing.T) {
+	d, mock, buf := explainDB(t)
+	d.settings().Database.ExplainSlowQueries = false
+	mock.ExpectQuery("SELECT id FROM orders").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := d.Query(context.Background(), "SELECT id FROM orders")
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"slow query"}, loggedMessages(t, buf))
+}
+
+// loggedArgsOf runs an insert with argument logging on and returns the args
+// of the "query" entry
+func loggedArgsOf(t *testing.T, opts ...Option) []interface{} {
+	t.Helper()
+	log, buf := bufferLogger(t)
+	d, mock := newMockDB(t, append([]Option{WithLogger(log)}, opts...)...)
+	d.settings().Database.LogArgs = true
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs("ada@example.com", "hunter2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	_, err := d.Exec(context.Background(), "INSERT INTO users (email, password) VALUES (?, ?)", "ada@example.com", "hunter2")
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	got := loggedEntries(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "query", got[0]["msg"])
+	return got[0]["fields"].(map[string]interface{})["args"].([]interface{})
+}
+
+func TestArgRedactorMasksLoggedArgs(t *testing.T) {
+	var seenQuery string
+	redact := func(query string, args []interface{}) []interface{} {
+		seenQuery = query
+		masked := append([]interface{}(nil), args...)
+		masked[1] = "***"
+		return masked
+	}
+
+	args := loggedArgsOf(t, WithArgRedactor(redact))
+
+	assert.Equal(t, []interface{}{"ada@example.com", "***"}, args)
+	assert.Equal(t, "INSERT INTO users (email, password) VALUES (?, ?)", seenQuery)
+}
+
+func TestArgsLoggedRawWithoutRedactor(t *testing.T) {
+	assert.Equal(t, []interface{}{"ada@example.com", "hunter2"}, loggedArgsOf(t))
+}
+
+// slowLogDB returns a db that logs to the returned main buffer and writes
+// slow queries to the returned slow-log buffer
+func slowLogDB(t *testing.T, threshold time.Duration) (*db, sqlmock.Sqlmock, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	log, main := bufferLogger(t)
+	slow := &bytes.Buffer{}
+	d, mock := newMockDB(t, WithLogger(log), WithSlowLog(slow))
+	d.settings().Database.SlowQueryThreshold = threshold
+	return d, mock, main, slow
+}
+
+func TestSlowLogReceivesSlowQueryInsteadOfLogger(t *testing.T) {
+	d, mock, main, slow := slowLogDB(t, time.Nanosecond)
+	mock.ExpectExec("^UPDATE orders SET status").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	before := time.Now()
+	_, err := d.Exec(context.Background(), "UPDATE orders SET status = ? WHERE status = ?", "shipped", "paid")
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, main.String(), "the main log does not get the slow query")
+
+	lines := loggedEntries(t, slow)
+	require.Len(t, lines, 1)
+	entry := lines[0]
+	assert.Equal(t, "UPDATE orders SET status = ? WHERE status = ?", entry["query"])
+	assert.Equal(t, 3.0, entry["rows"])
+	assert.GreaterOrEqual(t, entry["duration_ms"], 0.0)
+	ts, err := time.Parse(time.RFC3339Nano, entry["timestamp"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, before, ts, time.Second)
+}
+
+func TestSlowLogSkipsFastQueries(t *testing.T) {
+	d, mock, main, slow := slowLogDB(t, time.Hour)
+	mock.ExpectQuery("SELECT id FROM orders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	_, err := d.Query(context.Background(), "SELECT id FROM orders")
+
+	require.NoError(t, err)
+	assert.Empty(t, slow.String())
+	assert.Empty(t, main.String())
+}
+
+func TestSlowLogWritesOneLinePerQuery(t *testing.T) {
+	d, mock, _, slow := slowLogDB(t, time.Nanosecond)
+	mock.ExpectQuery("SELECT id FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec("DELETE FROM orders").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := d.Query(context.Background(), "SELECT id FROM orders")
+	require.NoError(t, err)
+	_, err = d.Exec(context.Background(), "DELETE FROM orders WHERE id = 1")
+	require.NoError(t, err)
+
+	lines := loggedEntries(t, slow)
+	require.Len(t, lines, 2)
+	assert.Equal(t, 2.0, lines[0]["rows"])
+	assert.Equal(t, "DELETE FROM orders WHERE id = 1", lines[1]["query"])
+}
+
+func TestSlowQueryGoesToLoggerWithoutSlowLog(t *testing.T) {
+	log, main := bufferLogger(t)
+	d, mock := newMockDB(t, WithLogger(log))
+	d.settings().Database.SlowQueryThreshold = time.Nanosecond
+	mock.ExpectExec("DELETE FROM orders").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := d.Exec(context.Background(), "DELETE FROM orders WHERE id = 1")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slow query"}, loggedMessages(t, main))
+}
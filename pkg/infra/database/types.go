@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -21,12 +22,33 @@ type Result struct {
 // Transaction represents a database transaction
 type Transaction interface {
 	Exec(ctx context.Context, query string, args ...interface{}) (*Result, error)
+	// ExecRows executes a query and returns the number of rows affected
+	ExecRows(ctx context.Context, query string, args ...interface{}) (int64, error)
 	Query(ctx context.Context, query string, args ...interface{}) ([]Row, error)
 	QueryRow(ctx context.Context, query string, args ...interface{}) Row
 	Commit() error
 	Rollback() error
+	// Savepoint creates a savepoint named name. Names must match
+	// [a-zA-Z_][a-zA-Z0-9_]* and be at most 64 characters.
+	Savepoint(ctx context.Context, name string) error
+	// RollbackTo rolls back to the named savepoint, keeping it
+	RollbackTo(ctx context.Context, name string) error
+	// ReleaseSavepoint removes the named savepoint without rolling back
+	ReleaseSavepoint(ctx context.Context, name string) error
 }
 
+// QueryHook observes database operations. Before may return a derived
+// context that is passed to the operation and to After. op names the
+// operation, e.g. "exec", "query", "transaction" or "tx.exec".
+type QueryHook interface {
+	Before(ctx context.Context, op string, query string, args []interface{}) context.Context
+	After(ctx context.Context, op string, query string, args []interface{}, err error, duration time.Duration)
+}
+
+// ArgRedactor returns the arguments of query as they should appear in
+// logs, e.g. with password positions masked. It must not modify args.
+type ArgRedactor func(query string, args []interface{}) []interface{}
+
 // Stats represents database statistics
 type Stats struct {
 	OpenConnections int
@@ -37,9 +59,16 @@ type Stats struct {
 	MaxIdleTime     time.Duration
 }
 
+// Error codes for database errors
+const (
+	// CodePoolExhausted means no connection could be acquired in time
+	CodePoolExhausted = "POOL_EXHAUSTED"
+)
+
 // Error represents a database error
 type Error struct {
 	Operation string
+	Code      string
 	Query     string
 	Err       error
 }
@@ -51,15 +80,29 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %v", e.Operation, e.Err)
 }
 
-// IsNoRows returns true if the error is sql.ErrNoRows
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// PanicError reports a panic in a transaction function that was recovered
+// and rolled back; see WithPanicAsError
+type PanicError struct {
+	Value interface{}
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("transaction function panicked: %v", e.Value)
+}
+
+// ErrNotFound is returned, wrapped in an *Error, when QueryRow finds no
+// row. It also matches sql.ErrNoRows for callers that still check for it.
+var ErrNotFound = errors.New("not found")
+
+// IsNoRows returns true if the error is ErrNotFound or sql.ErrNoRows
 func IsNoRows(err error) bool {
-	if err == sql.ErrNoRows {
-		return true
-	}
-	if dbErr, ok := err.(*Error); ok {
-		return dbErr.Err == sql.ErrNoRows
-	}
-	return false
+	return errors.Is(err, ErrNotFound) || errors.Is(err, sql.ErrNoRows)
 }
 
 // IsDuplicate returns true if the error is a duplicate key error
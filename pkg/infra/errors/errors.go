@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"runtime"
 	"strings"
@@ -25,17 +26,27 @@ func New(code string, message string) *Error {
 	}
 }
 
-// Wrap wraps an existing error with additional context
+// Wrap wraps an existing error with additional context. When err already
+// carries an *Error, its stack is kept since it points at the original
+// failure site rather than this wrap point.
 func Wrap(err error, code string, message string) *Error {
 	if err == nil {
 		return nil
 	}
 
+	stack := ""
+	var inner *Error
+	if stderrors.As(err, &inner) && inner.Stack != "" {
+		stack = inner.Stack
+	} else {
+		stack = getStackTrace()
+	}
+
 	return &Error{
 		Err:      err,
 		Code:     code,
 		Message:  message,
-		Stack:    getStackTrace(),
+		Stack:    stack,
 		Metadata: make(map[string]interface{}),
 	}
 }
@@ -48,6 +59,11 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the wrapped error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // WithMetadata adds metadata to the error
 func (e *Error) WithMetadata(key string, value interface{}) *Error {
 	e.Metadata[key] = value
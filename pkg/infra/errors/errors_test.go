@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// callerLine returns the line of its caller
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// failDeep fails a few frames down, returning the error and the line it
+// was created on
+func failDeep(depth int) (*Error, int) {
+	if depth > 0 {
+		return failDeep(depth - 1)
+	}
+	err, line := New("DB_TIMEOUT", "query timed out"), callerLine()
+	return err, line
+}
+
+func TestWrapKeepsOriginalStack(t *testing.T) {
+	origin, line := failDeep(3)
+
+	repo := Wrap(origin, "REPO_FAILED", "load order")
+	service := Wrap(fmt.Errorf("service: %w", repo), "SERVICE_FAILED", "place order")
+
+	assert.Equal(t, origin.Stack, service.Stack)
+	first := strings.SplitN(service.Stack, "\n", 2)[0]
+	assert.True(t, strings.HasSuffix(first, fmt.Sprintf("errors_test.go:%d", line)), first)
+	assert.Equal(t, "SERVICE_FAILED", service.Code)
+	assert.Equal(t, "place order", service.Message)
+
+	var inner *Error
+	require.ErrorAs(t, service.Err, &inner)
+	assert.Equal(t, "REPO_FAILED", inner.Code)
+}
+
+func TestWrapCapturesStackForPlainErrors(t *testing.T) {
+	err, line := Wrap(fmt.Errorf("plain"), "WRAPPED", "context"), callerLine()
+
+	first := strings.SplitN(err.Stack, "\n", 2)[0]
+	assert.True(t, strings.HasSuffix(first, fmt.Sprintf("errors_test.go:%d", line)), first)
+	assert.Nil(t, Wrap(nil, "WRAPPED", "context"))
+}
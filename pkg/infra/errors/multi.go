@@ -0,0 +1,67 @@
+package errors
+
+import (
+	stderrors "errors"
+	"strings"
+)
+
+// MultiError combines several errors into one
+type MultiError struct {
+	Errors []error
+}
+
+// Join combines the non-nil errs. It returns nil if there are none and the
+// error itself if there is exactly one.
+func Join(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return &MultiError{Errors: nonNil}
+	}
+}
+
+// Error implements the error interface, one error per line
+func (m *MultiError) Error() string {
+	msgs := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the combined errors
+func (m *MultiError) Unwrap() []error {
+	return m.Errors
+}
+
+// Is reports whether any combined error matches target, for toolchains
+// whose errors.Is doesn't follow Unwrap() []error
+func (m *MultiError) Is(target error) bool {
+	for _, err := range m.Errors {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first combined error that matches target, for toolchains
+// whose errors.As doesn't follow Unwrap() []error
+func (m *MultiError) As(target interface{}) bool {
+	for _, err := range m.Errors {
+		if stderrors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
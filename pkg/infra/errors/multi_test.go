@@ -0,0 +1,41 @@
+package errors
+
+import (
+	stderrors "errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinSkipsNil(t *testing.T) {
+	assert.NoError(t, Join())
+	assert.NoError(t, Join(nil, nil))
+}
+
+func TestJoinPassesSingleErrorThrough(t *testing.T) {
+	err := Join(nil, io.EOF, nil)
+	assert.Same(t, io.EOF, err)
+}
+
+func TestJoinFormatsOneErrorPerLine(t *testing.T) {
+	err := Join(stderrors.New("first"), nil, stderrors.New("second"))
+
+	var multi *MultiError
+	require.ErrorAs(t, err, &multi)
+	assert.Len(t, multi.Errors, 2)
+	assert.Equal(t, "first\nsecond", err.Error())
+}
+
+func TestJoinMatchesCombinedErrors(t *testing.T) {
+	coded := New("E1", "coded")
+	err := Join(stderrors.New("plain"), io.ErrUnexpectedEOF, coded)
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.NotErrorIs(t, err, io.EOF)
+
+	var target *Error
+	require.ErrorAs(t, err, &target)
+	assert.Equal(t, "E1", target.Code)
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// TokenSource returns a bearer token
+type TokenSource func(ctx context.Context) (string, error)
+
+// authTransport sets a bearer token on each request and, when the server
+// answers 401, refreshes the token and retries the request once. The
+// refreshed token replaces the token source's until the next 401.
+type authTransport struct {
+	base      http.RoundTripper
+	token     TokenSource
+	refresher *tokenRefresher
+
+	mu      sync.Mutex
+	current string // token from the last refresh, if any
+}
+
+// newAuthTransport wraps base with bearer token handling. refresh may be
+// nil, in which case a 401 is returned as is.
+func newAuthTransport(base http.RoundTripper, token, refresh TokenSource) http.RoundTripper {
+	t := &authTransport{base: base, token: token}
+	if refresh != nil {
+		t.refresher = &tokenRefresher{refresh: refresh}
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	token, err := t.bearer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || t.refresher == nil {
+		return resp, err
+	}
+
+	// The body must be replayable to retry; requests built by the client
+	// always are
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	fresh, err := t.refresher.refreshed(ctx, token)
+	if err != nil {
+		return resp, nil
+	}
+	t.mu.Lock()
+	t.current = fresh
+	t.mu.Unlock()
+
+	retry := withBearer(req, fresh)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// bearer returns the token from the last refresh, or else one from the
+// token source
+func (t *authTransport) bearer(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	current := t.current
+	t.mu.Unlock()
+	if current != "" {
+		return current, nil
+	}
+	return t.token(ctx)
+}
+
+// withBearer returns a copy of req carrying token in Authorization
+func withBearer(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+	return clone
+}
+
+// tokenRefresher runs at most one refresh at a time, sharing its result
+// with every caller that saw the same stale token
+type tokenRefresher struct {
+	refresh TokenSource
+
+	mu       sync.Mutex
+	inflight *refreshCall
+	stale    string // token replaced by the last successful refresh
+	fresh    string // token returned by the last successful refresh
+}
+
+// refreshCall is a refresh in progress
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// refreshed returns a token to use instead of stale, refreshing unless
+// another caller already has
+func (r *tokenRefresher) refreshed(ctx context.Context, stale string) (string, error) {
+	r.mu.Lock()
+	if r.stale == stale && r.fresh != "" {
+		fresh := r.fresh
+		r.mu.Unlock()
+		return fresh, nil
+	}
+	if call := r.inflight; call != nil {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.token, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	r.inflight = call
+	r.mu.Unlock()
+
+	call.token, call.err = r.refresh(ctx)
+
+	r.mu.Lock()
+	r.inflight = nil
+	if call.err == nil {
+		r.stale, r.fresh = stale, call.token
+	}
+	r.mu.Unlock()
+	close(call.done)
+
+	return call.token, call.err
+}
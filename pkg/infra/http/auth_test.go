@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer accepts only requests bearing valid and records the
+// Authorization header and body of every request
+type tokenServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	auths  []string
+	bodies []string
+}
+
+func newTokenServer(t *testing.T, valid string) *tokenServer {
+	t.Helper()
+	s := &tokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.auths = append(s.auths, r.Header.Get("Authorization"))
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tokenServer) seen() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.auths...)
+}
+
+// staticToken returns a TokenSource always yielding token
+func staticToken(token string) TokenSource {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
+func TestUnauthorizedRefreshesAndRetries(t *testing.T) {
+	srv := newTokenServer(t, "fresh")
+	var refreshes int32
+	refresh := func(context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "fresh", nil
+	}
+	c := NewClient(testConfig(), srv.URL, WithTokenSource(staticToken("stale"), refresh))
+
+	resp, err := c.Post(context.Background(), "/orders", []byte(`{"id":1}`), &RequestOption{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer stale", "Bearer fresh"}, srv.seen())
+	assert.Equal(t, []string{`{"id":1}`, `{"id":1}`}, srv.bodies, "the body is replayed")
+
+	_, err = c.Get(context.Background(), "/orders", &RequestOption{})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer fresh", srv.seen()[2], "the refreshed token is stored")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+}
+
+func TestConcurrentUnauthorizedRefreshOnce(t *testing.T) {
+	srv := newTokenServer(t, "fresh")
+	var refreshes int32
+	refresh := func(context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "fresh", nil
+	}
+	c := NewClient(testConfig(), srv.URL, WithTokenSource(staticToken("stale"), refresh))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(context.Background(), "/orders", &RequestOption{TreatNon2xxAsError: true})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+}
+
+func TestUnauthorizedWithoutRefresherReturned(t *testing.T) {
+	srv := newTokenServer(t, "fresh")
+	c := NewClient(testConfig(), srv.URL, WithTokenSource(staticToken("stale"), nil))
+
+	resp, err := c.Get(context.Background(), "/orders", &RequestOption{})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer stale"}, srv.seen())
+}
+
+func TestUnauthorizedAfterRefreshGivesUp(t *testing.T) {
+	srv := newTokenServer(t, "never")
+	var refreshes int32
+	refresh := func(context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "fresh", nil
+	}
+	c := NewClient(testConfig(), srv.URL, WithTokenSource(staticToken("stale"), refresh))
+
+	resp, err := c.Get(context.Background(), "/orders", &RequestOption{})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer stale", "Bearer fresh"}, srv.seen(), "retried once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+}
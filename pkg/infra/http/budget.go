@@ -0,0 +1,59 @@
+package http
+
+import (
+	"sync"
+)
+
+// retryBudgetMaxTokens caps the retries that can be banked while healthy
+const retryBudgetMaxTokens = 10
+
+// retryBudget is a client-wide token bucket that limits retries to a
+// fraction of successful requests, so a broad outage doesn't turn into a
+// retry storm against the downstream
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+// newRetryBudget creates a retry budget, or nil if ratio disables it
+func newRetryBudget(ratio float64) *retryBudget {
+	if ratio <= 0 {
+		return nil
+	}
+	return &retryBudget{
+		ratio:  ratio,
+		tokens: retryBudgetMaxTokens,
+	}
+}
+
+// deposit credits the budget for a successful request
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > retryBudgetMaxTokens {
+		b.tokens = retryBudgetMaxTokens
+	}
+}
+
+// withdraw reports whether a retry is allowed, consuming a token if so
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
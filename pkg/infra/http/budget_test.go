@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudgetTapersRetriesDuringOutage(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusInternalServerError)
+	cfg := testConfig()
+	cfg.HTTP.RetryBudgetRatio = 0.1
+	c := NewClient(cfg, srv.URL)
+
+	var attempts []int64
+	for i := 0; i < 20; i++ {
+		before := atomic.LoadInt64(hits)
+		_, err := c.Get(context.Background(), "/", failingOpt(3))
+		assert.Error(t, err)
+		attempts = append(attempts, atomic.LoadInt64(hits)-before)
+	}
+
+	assert.Equal(t, int64(4), attempts[0], "a full budget allows every retry")
+	assert.Equal(t, int64(1), attempts[len(attempts)-1], "a spent budget allows none")
+	for i := 1; i < len(attempts); i++ {
+		assert.LessOrEqual(t, attempts[i], attempts[i-1])
+	}
+	assert.Equal(t, int64(20+retryBudgetMaxTokens), atomic.LoadInt64(hits))
+}
+
+func TestRetryBudgetRefillsOnSuccess(t *testing.T) {
+	b := newRetryBudget(0.5)
+	for b.withdraw() {
+	}
+	assert.False(t, b.withdraw())
+
+	b.deposit()
+	assert.False(t, b.withdraw(), "half a token is not a retry")
+	b.deposit()
+	assert.True(t, b.withdraw())
+}
+
+func TestRetryBudgetDisabled(t *testing.T) {
+	b := newRetryBudget(0)
+	assert.Nil(t, b)
+	for i := 0; i < 2*retryBudgetMaxTokens; i++ {
+		assert.True(t, b.withdraw())
+	}
+}
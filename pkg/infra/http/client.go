@@ -3,38 +3,129 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"time"
 
+	"golang.org/x/net/http2"
+
+	"order-system/pkg/infra/concurrent"
 	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/logger"
+	"order-system/pkg/platform/trace"
+)
+
+// maxErrorBodySize caps the body snippet kept on an *Error
+const maxErrorBodySize = 1024
+
+// Defaults for RequestOption fields left unset
+const (
+	defaultRetryCount    = 3
+	defaultRetryInterval = time.Second
+	defaultMaxBodySize   = 10 << 20
 )
 
 // defaultClient represents the default HTTP client implementation
 type defaultClient struct {
-	client  *http.Client
-	config  *config.Config
-	baseURL string
+	client   *http.Client
+	config   *config.Config
+	baseURL  string
+	budget   *retryBudget
+	throttle *adaptiveThrottle
+	log      logger.Logger
+	options  ClientOptions
+}
+
+// NewClient creates a new HTTP client configured by opts
+func NewClient(cfg *config.Config, baseURL string, opts ...Option) Client {
+	var o ClientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newClient(cfg, baseURL, o)
+}
+
+// NewClientWithOptions creates a new HTTP client configured by opts.
+//
+// Deprecated: use NewClient, which has an Option for every ClientOptions
+// field.
+func NewClientWithOptions(cfg *config.Config, baseURL string, opts ClientOptions) Client {
+	return newClient(cfg, baseURL, opts)
 }
 
-// NewClient creates a new HTTP client
-func NewClient(cfg *config.Config, baseURL string) Client {
+// NewClientWithLogger creates a new HTTP client that logs request and
+// response details through log when cfg.HTTP.Debug is set.
+//
+// Deprecated: use NewClient(cfg, baseURL, WithLogger(log)).
+func NewClientWithLogger(cfg *config.Config, baseURL string, log logger.Logger) Client {
+	return NewClient(cfg, baseURL, WithLogger(log))
+}
+
+// newClient creates a new HTTP client from the resolved options
+func newClient(cfg *config.Config, baseURL string, opts ClientOptions) *defaultClient {
+	transport := &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		MaxConnsPerHost:     100,
+		IdleConnTimeout:     90 * time.Second,
+		DisableKeepAlives:   cfg.HTTP.DisableKeepAlives,
+	}
+
 	client := &http.Client{
-		Timeout: cfg.HTTP.RequestTimeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 100,
-			MaxConnsPerHost:     100,
-			IdleConnTimeout:     90 * time.Second,
-		},
+		Timeout:   cfg.HTTP.RequestTimeout,
+		Transport: configureProtocols(transport, cfg.HTTP.Protocol),
 	}
 
-	return &defaultClient{
-		client:  client,
-		config:  cfg,
-		baseURL: baseURL,
+	c := &defaultClient{
+		client:   client,
+		config:   cfg,
+		baseURL:  baseURL,
+		budget:   newRetryBudget(cfg.HTTP.RetryBudgetRatio),
+		throttle: newAdaptiveThrottle(opts.ThrottleRatio),
+		options:  opts,
 	}
+	if opts.Transport != nil {
+		c.client.Transport = opts.Transport
+	}
+	if opts.TokenSource != nil {
+		c.client.Transport = newAuthTransport(c.client.Transport, opts.TokenSource, opts.RefreshToken)
+	}
+	if opts.Logger != nil {
+		c.log = opts.Logger.WithComponent("http")
+	}
+	return c
+}
+
+// configureProtocols sets the protocols transport may use and returns the
+// round tripper the client sends requests through. An empty mode leaves the
+// transport's defaults untouched.
+func configureProtocols(transport *http.Transport, mode string) http.RoundTripper {
+	switch mode {
+	case ProtocolHTTP1:
+		transport.ForceAttemptHTTP2 = false
+		// A non-nil empty map stops the transport from upgrading to h2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case ProtocolHTTP2:
+		transport.ForceAttemptHTTP2 = true
+		// Only fails if h2 is already registered, which a fresh transport
+		// never has; the forced attempt still negotiates h2 then
+		_, _ = http2.ConfigureTransports(transport)
+	case ProtocolH2C:
+		// h2c speaks HTTP/2 with prior knowledge, so the TLS dial of the
+		// HTTP/2 transport is replaced by a plain TCP one
+		return &http2.Transport{
+			AllowHTTP:       true,
+			IdleConnTimeout: transport.IdleConnTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}
+	}
+	return transport
 }
 
 // Get performs a GET request
@@ -59,39 +150,133 @@ func (c *defaultClient) Delete(ctx context.Context, url string, opt *RequestOpti
 
 // do performs the HTTP request with retries
 func (c *defaultClient) do(ctx context.Context, method, url string, body []byte, opt *RequestOption) (*Response, error) {
-	if opt == nil {
-		opt = &RequestOption{
-			Timeout:       c.config.HTTP.RequestTimeout,
-			RetryCount:    3,
-			RetryInterval: time.Second,
-			MaxBodySize:   c.config.HTTP.MaxRequestSize,
+	opt = c.withDefaults(opt)
+	body, err := compressBody(body, opt)
+	if err != nil {
+		return nil, &Error{
+			Message: "failed to compress request body",
+			Cause:   err,
 		}
 	}
 
 	var resp *Response
 	var lastErr error
+	var attemptErrs []error
+	backoff := concurrent.NewBackoff(opt.RetryInterval, opt.RetryMultiplier, opt.MaxRetryInterval)
 
 	for i := 0; i <= opt.RetryCount; i++ {
-		resp, lastErr = c.doRequest(ctx, method, url, body, opt)
+		attemptCtx, cancel := attemptContext(ctx, opt, opt.RetryCount-i+1)
+		spanCtx, finish := trace.StartSpan(attemptCtx, "http."+method)
+		resp, lastErr = c.doRequest(spanCtx, method, url, body, opt)
+		// An attempt that ran out of its share of a split deadline is
+		// retried while the caller's own deadline still allows it
+		expired := attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		finish(lastErr)
+		cancel()
+		retryable := c.shouldRetry(lastErr) || (lastErr != nil && expired)
+		c.throttle.record(!retryable)
 		if lastErr == nil {
+			c.budget.deposit()
 			return resp, nil
 		}
+		attemptErrs = append(attemptErrs, lastErr)
 
 		// Check if we should retry
-		if !c.shouldRetry(lastErr) || i == opt.RetryCount {
+		if !retryable || i == opt.RetryCount {
+			break
+		}
+
+		// Repeating a non-idempotent request could apply it twice
+		if !opt.RetryNonIdempotent && !isIdempotent(method) {
+			break
+		}
+
+		// Back off while the downstream fails most attempts
+		if c.throttle.reject() {
+			return nil, &ThrottledError{LastErr: withAttempts(lastErr, attemptErrs)}
+		}
+
+		// Stop retrying once the client-wide retry budget is spent
+		if !c.budget.withdraw() {
 			break
 		}
 
-		// Wait before retrying
+		delay := backoff.Next()
+		if opt.OnRetry != nil {
+			opt.OnRetry(i+1, lastErr, delay)
+		}
+
+		// Wait before retrying. The previous attempt has already released its
+		// response body, so only the timer needs cleaning up on cancel.
+		timer := time.NewTimer(delay)
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-time.After(opt.RetryInterval):
+			timer.Stop()
+			return nil, &RetryCancelledError{
+				Err:     ctx.Err(),
+				LastErr: withAttempts(lastErr, attemptErrs),
+			}
+		case <-timer.C:
 			continue
 		}
 	}
 
-	return nil, lastErr
+	return nil, withAttempts(lastErr, attemptErrs)
+}
+
+// withAttempts records the retry history on err when it is an *Error. The
+// history holds a copy of err without a history of its own, so the error
+// does not contain itself.
+func withAttempts(err error, attemptErrs []error) error {
+	httpErr, ok := err.(*Error)
+	if !ok {
+		return err
+	}
+	history := make([]error, len(attemptErrs))
+	for i, attemptErr := range attemptErrs {
+		if attemptErr == err {
+			last := *httpErr
+			attemptErr = &last
+		}
+		history[i] = attemptErr
+	}
+	httpErr.Attempts = len(history)
+	httpErr.AttemptErrors = history
+	return err
+}
+
+// withDefaults returns a copy of opt with zero fields filled from the
+// client config or package defaults. A nil opt also gets the default retry
+// count; otherwise a zero RetryCount means no retries.
+func (c *defaultClient) withDefaults(opt *RequestOption) *RequestOption {
+	if opt == nil {
+		opt = &RequestOption{RetryCount: defaultRetryCount}
+	}
+	filled := *opt
+	if filled.Timeout <= 0 {
+		filled.Timeout = c.config.HTTP.RequestTimeout
+	}
+	if filled.RetryInterval <= 0 {
+		filled.RetryInterval = defaultRetryInterval
+	}
+	if filled.MaxBodySize <= 0 {
+		filled.MaxBodySize = c.config.HTTP.MaxRequestSize
+	}
+	if filled.MaxBodySize <= 0 {
+		filled.MaxBodySize = defaultMaxBodySize
+	}
+	return &filled
+}
+
+// attemptContext returns the context for a single attempt. With
+// SplitDeadline set and a deadline on ctx, the attempt gets the remaining
+// time divided by the attempts left.
+func attemptContext(ctx context.Context, opt *RequestOption, attemptsLeft int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !opt.SplitDeadline || !ok || attemptsLeft <= 1 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
 }
 
 // doRequest performs a single HTTP request
@@ -105,14 +290,21 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 		}
 	}
 
-	// Add headers
+	// Add headers, letting explicit ones override those from the context
+	for key, name := range c.options.HeaderFromContext {
+		if v := contextHeaderValue(ctx, key); v != "" {
+			req.Header.Set(name, v)
+		}
+	}
 	for k, v := range opt.Headers {
 		req.Header.Set(k, v)
 	}
+	c.logRequest(ctx, req, body, opt)
 
 	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
+		c.logError(ctx, req, err)
 		return nil, &Error{
 			Message: "request failed",
 			Cause:   err,
@@ -137,6 +329,19 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 			Cause:      err,
 		}
 	}
+	c.logResponse(ctx, req, resp, respBody, time.Since(start))
+
+	if opt.TreatNon2xxAsError && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		snippet := respBody
+		if len(snippet) > maxErrorBodySize {
+			snippet = snippet[:maxErrorBodySize]
+		}
+		return nil, &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("unexpected status code: %d", resp.StatusCode),
+			Body:       snippet,
+		}
+	}
 
 	return &Response{
 		StatusCode: resp.StatusCode,
@@ -146,6 +351,31 @@ func (c *defaultClient) doRequest(ctx context.Context, method, url string, body
 	}, nil
 }
 
+// contextHeaderValue returns the value stored under key in ctx as a
+// header value, or "" if it is absent
+func contextHeaderValue(ctx context.Context, key interface{}) string {
+	switch v := ctx.Value(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isIdempotent reports whether method may be retried without opting in
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
 // shouldRetry determines if a request should be retried
 func (c *defaultClient) shouldRetry(err error) bool {
 	if err == nil {
@@ -0,0 +1,587 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"order-system/pkg/infra/config"
+	"order-system/pkg/platform/trace"
+)
+
+// testConfig returns a client config with no request timeout or budget
+func testConfig() *config.Config {
+	return &config.Config{}
+}
+
+// failingOpt retries server errors without waiting between attempts
+func failingOpt(retries int) *RequestOption {
+	return &RequestOption{
+		RetryCount:         retries,
+		RetryInterval:      time.Nanosecond,
+		TreatNon2xxAsError: true,
+	}
+}
+
+// countingServer serves status to every request and counts the requests
+func countingServer(t *testing.T, status int) (*httptest.Server, *int64) {
+	t.Helper()
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// recordSpans installs a recording global tracer for the test
+func recordSpans(t *testing.T) *[]trace.Span {
+	t.Helper()
+	var spans []trace.Span
+	trace.SetTracer(trace.NewRecorder(func(s trace.Span) { spans = append(spans, s) }))
+	t.Cleanup(func() { trace.SetTracer(nil) })
+	return &spans
+}
+
+func TestRequestSpans(t *testing.T) {
+	spans := recordSpans(t)
+	ok, _ := countingServer(t, http.StatusOK)
+	failing, _ := countingServer(t, http.StatusBadGateway)
+
+	_, err := NewClient(testConfig(), ok.URL).Get(context.Background(), "/", failingOpt(0))
+	require.NoError(t, err)
+	_, err = NewClient(testConfig(), failing.URL).Delete(context.Background(), "/", failingOpt(1))
+	require.Error(t, err)
+
+	require.Len(t, *spans, 3, "one span per attempt")
+	assert.Equal(t, "http.GET", (*spans)[0].Name)
+	assert.NoError(t, (*spans)[0].Err)
+	for _, s := range (*spans)[1:] {
+		assert.Equal(t, "http.DELETE", s.Name)
+		assert.Error(t, s.Err)
+	}
+}
+
+func TestRetryWaitCancelledCarriesBothCauses(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := failingOpt(3)
+	opt.RetryInterval = time.Hour
+	opt.OnRetry = func(int, error, time.Duration) { cancel() }
+
+	_, err := NewClient(testConfig(), srv.URL).Get(ctx, "/", opt)
+
+	var cancelled *RetryCancelledError
+	require.ErrorAs(t, err, &cancelled)
+	assert.ErrorIs(t, err, context.Canceled)
+	var httpErr *Error
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	assert.Equal(t, int64(1), atomic.LoadInt64(hits))
+}
+
+func TestRetryWaitDeadlineDistinguishedFromCancel(t *testing.T) {
+	srv, _ := countingServer(t, http.StatusInternalServerError)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	opt := failingOpt(3)
+	opt.RetryInterval = time.Hour
+
+	start := time.Now()
+	_, err := NewClient(testConfig(), srv.URL).Get(ctx, "/", opt)
+
+	assert.Less(t, time.Since(start), time.Second, "the wait ends with the context")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "last attempt: unexpected status code: 500")
+}
+
+// protoServer starts a server that answers with the request protocol,
+// over TLS with HTTP/2 enabled or over cleartext with h2c enabled
+func protoServer(t *testing.T, tlsServer bool) *httptest.Server {
+	t.Helper()
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+	if !tlsServer {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	if tlsServer {
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// negotiatedProto returns the protocol a client in mode uses with srv
+func negotiatedProto(t *testing.T, srv *httptest.Server, mode string) string {
+	t.Helper()
+	cfg := testConfig()
+	cfg.HTTP.Protocol = mode
+	c := newClient(cfg, srv.URL, ClientOptions{})
+	if srv.Certificate() != nil {
+		roots := x509.NewCertPool()
+		roots.AddCert(srv.Certificate())
+		transport := c.client.Transport.(*http.Transport)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = roots
+	}
+
+	resp, err := c.Get(context.Background(), "/", &RequestOption{})
+	require.NoError(t, err)
+	return string(resp.Body)
+}
+
+func TestNegotiatedProtocol(t *testing.T) {
+	tlsSrv := protoServer(t, true)
+	cleartextSrv := protoServer(t, false)
+
+	assert.Equal(t, "HTTP/1.1", negotiatedProto(t, tlsSrv, ProtocolHTTP1))
+	assert.Equal(t, "HTTP/2.0", negotiatedProto(t, tlsSrv, ProtocolHTTP2))
+	assert.Equal(t, "HTTP/2.0", negotiatedProto(t, cleartextSrv, ProtocolH2C))
+	assert.Equal(t, "HTTP/1.1", negotiatedProto(t, cleartextSrv, ""))
+}
+
+func TestEmptyProtocolKeepsBaselineTransport(t *testing.T) {
+	c := newClient(testConfig(), "", ClientOptions{})
+	transport := c.client.Transport.(*http.Transport)
+
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.Nil(t, transport.TLSNextProto)
+}
+
+func TestTreatNon2xxAsErrorReturnsStructuredError(t *testing.T) {
+	big := strings.Repeat("x", 2*maxErrorBodySize)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(big))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"out of stock"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(testConfig(), srv.URL)
+
+	_, err := c.Get(context.Background(), "/", failingOpt(0))
+	var httpErr *Error
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	assert.Equal(t, "unexpected status code: 500", httpErr.Message)
+	assert.JSONEq(t, `{"error":"out of stock"}`, string(httpErr.Body))
+
+	_, err = c.Get(context.Background(), "/big", failingOpt(0))
+	require.ErrorAs(t, err, &httpErr)
+	assert.Len(t, httpErr.Body, maxErrorBodySize, "the body snippet is capped")
+}
+
+func TestNon2xxReturnsResponseByDefault(t *testing.T) {
+	srv, _ := countingServer(t, http.StatusInternalServerError)
+	resp, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", &RequestOption{})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestTreatNon2xxAsErrorReturns200Normally(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", failingOpt(0))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ok", string(resp.Body))
+}
+
+func TestAttemptContextShrinksAsDeadlineApproaches(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	opt := &RequestOption{SplitDeadline: true}
+
+	// remaining returns how long the attempt context has left
+	remaining := func(attemptsLeft int) time.Duration {
+		attemptCtx, cancel := attemptContext(ctx, opt, attemptsLeft)
+		defer cancel()
+		deadline, ok := attemptCtx.Deadline()
+		require.True(t, ok)
+		return time.Until(deadline)
+	}
+
+	first := remaining(3)
+	assert.LessOrEqual(t, first, 100*time.Millisecond, "a third of the budget")
+	time.Sleep(150 * time.Millisecond)
+	later := remaining(3)
+	assert.Less(t, later, first/2+10*time.Millisecond, "shares shrink with the time left")
+
+	last, cancelLast := attemptContext(ctx, opt, 1)
+	defer cancelLast()
+	assert.Equal(t, ctx, last, "the last attempt gets whatever is left")
+	unsplit, cancelUnsplit := attemptContext(ctx, &RequestOption{}, 3)
+	defer cancelUnsplit()
+	assert.Equal(t, ctx, unsplit)
+}
+
+func TestSplitDeadlineLeavesTimeForRetries(t *testing.T) {
+	spans := recordSpans(t)
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	opt := failingOpt(2)
+	opt.SplitDeadline = true
+
+	_, err := NewClient(testConfig(), srv.URL).Get(ctx, "/", opt)
+
+	require.Error(t, err)
+	assert.Equal(t, int64(3), atomic.LoadInt64(&hits), "every attempt ran within the budget")
+	require.Len(t, *spans, 3)
+	for _, s := range *spans {
+		assert.Less(t, s.Duration, 250*time.Millisecond, "no attempt spends the whole budget")
+	}
+}
+
+func TestOnRetryCalledOncePerRetry(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+	type retry struct {
+		attempt int
+		err     error
+		delay   time.Duration
+	}
+	var retries []retry
+	opt := failingOpt(3)
+	opt.OnRetry = func(attempt int, err error, nextDelay time.Duration) {
+		retries = append(retries, retry{attempt, err, nextDelay})
+	}
+
+	_, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", opt)
+
+	require.Error(t, err)
+	assert.Equal(t, int64(4), atomic.LoadInt64(hits))
+	require.Len(t, retries, 3, "no call after the final attempt")
+	for i, r := range retries {
+		assert.Equal(t, i+1, r.attempt)
+		assert.Positive(t, r.delay)
+		var httpErr *Error
+		require.ErrorAs(t, r.err, &httpErr)
+		assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	}
+}
+
+func TestOnRetryNotCalledOnSuccess(t *testing.T) {
+	srv, _ := countingServer(t, http.StatusOK)
+	opt := failingOpt(3)
+	opt.OnRetry = func(int, error, time.Duration) { t.Error("OnRetry called without a retry") }
+
+	_, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", opt)
+	require.NoError(t, err)
+}
+
+func TestTransportMatchesProtocolConfig(t *testing.T) {
+	newTransport := func(mode string) http.RoundTripper {
+		cfg := testConfig()
+		cfg.HTTP.Protocol = mode
+		return newClient(cfg, "", ClientOptions{}).client.Transport
+	}
+
+	http1 := newTransport(ProtocolHTTP1).(*http.Transport)
+	assert.False(t, http1.ForceAttemptHTTP2)
+	assert.NotNil(t, http1.TLSNextProto, "h2 upgrade disabled")
+	assert.Empty(t, http1.TLSNextProto)
+
+	http2Transport := newTransport(ProtocolHTTP2).(*http.Transport)
+	assert.True(t, http2Transport.ForceAttemptHTTP2)
+	assert.Contains(t, http2Transport.TLSNextProto, "h2")
+	require.NotNil(t, http2Transport.TLSClientConfig)
+	assert.Contains(t, http2Transport.TLSClientConfig.NextProtos, "h2")
+
+	h2cTransport, ok := newTransport(ProtocolH2C).(*http2.Transport)
+	require.True(t, ok, "h2c uses the HTTP/2 transport directly")
+	assert.True(t, h2cTransport.AllowHTTP)
+}
+
+func TestTransportKeepAliveToggle(t *testing.T) {
+	cfg := testConfig()
+	transport := newClient(cfg, "", ClientOptions{}).client.Transport.(*http.Transport)
+	assert.False(t, transport.DisableKeepAlives, "keep-alives on by default")
+
+	cfg.HTTP.DisableKeepAlives = true
+	transport = newClient(cfg, "", ClientOptions{}).client.Transport.(*http.Transport)
+	assert.True(t, transport.DisableKeepAlives)
+}
+
+func TestFailingPostNotRetriedByDefault(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/orders", []byte("{}"), failingOpt(3))
+
+	require.Error(t, err)
+	assert.Equal(t, int64(1), atomic.LoadInt64(hits))
+}
+
+func TestFailingPostRetriedWhenOptedIn(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+	opt := failingOpt(3)
+	opt.RetryNonIdempotent = true
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/orders", []byte("{}"), opt)
+
+	require.Error(t, err)
+	assert.Equal(t, int64(4), atomic.LoadInt64(hits))
+}
+
+func TestIdempotentMethodsRetried(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+	c := NewClient(testConfig(), srv.URL)
+
+	_, _ = c.Get(context.Background(), "/", failingOpt(1))
+	_, _ = c.Put(context.Background(), "/", nil, failingOpt(1))
+	_, _ = c.Delete(context.Background(), "/", failingOpt(1))
+
+	assert.Equal(t, int64(6), atomic.LoadInt64(hits), "two attempts each")
+}
+
+// headerServer records the headers of the last request it served
+func headerServer(t *testing.T) (*httptest.Server, func() http.Header) {
+	t.Helper()
+	var mu sync.Mutex
+	var last http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		last = r.Header.Clone()
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+type ctxKey string
+
+func TestHeaderFromContext(t *testing.T) {
+	srv, lastHeader := headerServer(t)
+	c := NewClient(testConfig(), srv.URL,
+		WithHeaderFromContext(ctxKey("tenant"), "X-Tenant"),
+		WithHeaderFromContext(ctxKey("locale"), "Accept-Language"),
+	)
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+
+	_, err := c.Get(ctx, "/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", lastHeader().Get("X-Tenant"))
+	assert.Empty(t, lastHeader().Values("Accept-Language"), "absent context values send no header")
+
+	_, err = c.Get(ctx, "/", &RequestOption{Headers: map[string]string{"X-Tenant": "globex"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"globex"}, lastHeader().Values("X-Tenant"), "an explicit header wins")
+}
+
+func TestWithDefaultsFillsPartialOption(t *testing.T) {
+	cfg := testConfig()
+	cfg.HTTP.RequestTimeout = 5 * time.Second
+	cfg.HTTP.MaxRequestSize = 1 << 20
+	c := newClient(cfg, "", ClientOptions{})
+	opt := &RequestOption{RetryCount: 2, Headers: map[string]string{"X-Tenant": "acme"}}
+
+	filled := c.withDefaults(opt)
+
+	assert.Equal(t, 2, filled.RetryCount, "explicit fields kept")
+	assert.Equal(t, map[string]string{"X-Tenant": "acme"}, filled.Headers)
+	assert.Equal(t, 5*time.Second, filled.Timeout)
+	assert.Equal(t, defaultRetryInterval, filled.RetryInterval)
+	assert.Equal(t, int64(1<<20), filled.MaxBodySize)
+	assert.Equal(t, &RequestOption{RetryCount: 2, Headers: map[string]string{"X-Tenant": "acme"}}, opt, "the caller's option is not modified")
+}
+
+func TestWithDefaultsKeepsExplicitValues(t *testing.T) {
+	c := newClient(testConfig(), "", ClientOptions{})
+	opt := &RequestOption{Timeout: time.Second, RetryInterval: time.Millisecond, MaxBodySize: 64}
+
+	filled := c.withDefaults(opt)
+
+	assert.Equal(t, *opt, *filled)
+	assert.Zero(t, filled.RetryCount, "a zero RetryCount on a non-nil option means no retries")
+}
+
+func TestWithDefaultsForNilOption(t *testing.T) {
+	filled := newClient(testConfig(), "", ClientOptions{}).withDefaults(nil)
+
+	assert.Equal(t, defaultRetryCount, filled.RetryCount)
+	assert.Equal(t, defaultRetryInterval, filled.RetryInterval)
+	assert.Equal(t, int64(defaultMaxBodySize), filled.MaxBodySize, "package default without a configured limit")
+}
+
+func TestPartialOptionStillReadsResponseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", &RequestOption{RetryCount: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(resp.Body))
+}
+
+func TestErrorRecordsEveryAttemptAfterRetries(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flap between two failures so each attempt's error is distinct
+		if atomic.AddInt64(&hits, 1)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", failingOpt(2))
+
+	var httpErr *Error
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, 3, httpErr.Attempts)
+	require.Len(t, httpErr.AttemptErrors, 3)
+	var statuses []int
+	for _, attemptErr := range httpErr.AttemptErrors {
+		var e *Error
+		require.ErrorAs(t, attemptErr, &e)
+		statuses = append(statuses, e.StatusCode)
+	}
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusServiceUnavailable}, statuses)
+	last := httpErr.AttemptErrors[2].(*Error)
+	assert.NotSame(t, httpErr, last, "the history does not contain the returned error")
+	assert.Equal(t, httpErr.Error(), last.Error())
+	assert.Empty(t, last.AttemptErrors)
+}
+
+func TestErrorRecordsSingleAttemptWithoutRetry(t *testing.T) {
+	srv, _ := countingServer(t, http.StatusNotFound)
+
+	_, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", failingOpt(3))
+
+	var httpErr *Error
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, 1, httpErr.Attempts, "a 404 is not retried")
+	assert.Len(t, httpErr.AttemptErrors, 1)
+}
+
+// roundTripFunc is an http.RoundTripper backed by a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithTransportRoutesRequestsThroughStub(t *testing.T) {
+	var seen []string
+	stub := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = append(seen, r.Method+" "+r.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":7}`)),
+			Request:    r,
+		}, nil
+	})
+	c := NewClient(testConfig(), "http://orders.invalid", WithTransport(stub))
+
+	resp, err := c.Post(context.Background(), "/orders", []byte("{}"), nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST http://orders.invalid/orders"}, seen)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":7}`, string(resp.Body))
+}
+
+func TestWithTransportRetriesThroughStub(t *testing.T) {
+	calls := 0
+	stub := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    r,
+		}, nil
+	})
+
+	_, err := NewClient(testConfig(), "http://orders.invalid", WithTransport(stub)).Get(context.Background(), "/", failingOpt(2))
+
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestNewClientAppliesEveryOption(t *testing.T) {
+	var seen []string
+	stub := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = append(seen, r.Header.Get("Authorization")+" "+r.Header.Get("X-Tenant"))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})
+	c := NewClient(testConfig(), "http://orders.invalid",
+		WithTransport(stub),
+		WithHeaderFromContext(ctxKey("tenant"), "X-Tenant"),
+		WithTokenSource(staticToken("t-1"), nil),
+	)
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+
+	_, err := c.Get(ctx, "/", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer t-1 acme"}, seen)
+}
+
+func TestNewClientLaterOptionWins(t *testing.T) {
+	var first, second int
+	stub := func(n *int) http.RoundTripper {
+		return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			*n++
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+		})
+	}
+	c := NewClient(testConfig(), "http://orders.invalid", WithTransport(stub(&first)), WithTransport(stub(&second)))
+
+	_, err := c.Get(context.Background(), "/", nil)
+
+	require.NoError(t, err)
+	assert.Zero(t, first)
+	assert.Equal(t, 1, second)
+}
+
+func TestNewClientWithoutOptions(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusOK)
+
+	resp, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadInt64(hits))
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+)
+
+// defaultCompressThreshold is the smallest body compressed when
+// RequestOption.CompressThreshold is unset
+const defaultCompressThreshold = 1024
+
+// compressBody gzips body when opt asks for it and body is large enough,
+// adding Content-Encoding to opt.Headers. opt must be the request's own
+// copy, as returned by withDefaults.
+func compressBody(body []byte, opt *RequestOption) ([]byte, error) {
+	threshold := opt.CompressThreshold
+	if threshold <= 0 {
+		threshold = defaultCompressThreshold
+	}
+	if !opt.CompressRequest || len(body) < threshold {
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(opt.Headers)+1)
+	for k, v := range opt.Headers {
+		headers[k] = v
+	}
+	headers["Content-Encoding"] = "gzip"
+	opt.Headers = headers
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bodyRequest is a request body and its Content-Encoding as received
+type bodyRequest struct {
+	encoding string
+	body     []byte
+}
+
+// bodyServer records every request body, failing the first fail requests
+// with 503
+func bodyServer(t *testing.T, fail int) (*httptest.Server, func() []bodyRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []bodyRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		seen = append(seen, bodyRequest{encoding: r.Header.Get("Content-Encoding"), body: body})
+		n := len(seen)
+		mu.Unlock()
+		if n <= fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []bodyRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]bodyRequest(nil), seen...)
+	}
+}
+
+// gunzip decompresses data
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return plain
+}
+
+func TestCompressRequestGzipsLargeBody(t *testing.T) {
+	srv, seen := bodyServer(t, 0)
+	body := []byte(strings.Repeat(`{"sku":"A-1","qty":1},`, 100))
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/", body, &RequestOption{CompressRequest: true})
+
+	require.NoError(t, err)
+	got := seen()
+	require.Len(t, got, 1)
+	assert.Equal(t, "gzip", got[0].encoding)
+	assert.Less(t, len(got[0].body), len(body))
+	assert.Equal(t, body, gunzip(t, got[0].body))
+}
+
+func TestCompressRequestLeavesSmallBody(t *testing.T) {
+	srv, seen := bodyServer(t, 0)
+	body := []byte(`{"sku":"A-1"}`)
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/", body, &RequestOption{CompressRequest: true})
+
+	require.NoError(t, err)
+	got := seen()
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].encoding)
+	assert.Equal(t, body, got[0].body)
+}
+
+func TestCompressThresholdOverridesDefault(t *testing.T) {
+	srv, seen := bodyServer(t, 0)
+	body := []byte(strings.Repeat("a", 64))
+	opt := &RequestOption{CompressRequest: true, CompressThreshold: 32}
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/", body, opt)
+
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", seen()[0].encoding)
+}
+
+func TestLargeBodyUncompressedByDefault(t *testing.T) {
+	srv, seen := bodyServer(t, 0)
+	body := []byte(strings.Repeat("a", 4*defaultCompressThreshold))
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/", body, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, seen()[0].encoding)
+	assert.Equal(t, body, seen()[0].body)
+}
+
+func TestCompressedBodyResentOnRetry(t *testing.T) {
+	srv, seen := bodyServer(t, 2)
+	body := []byte(strings.Repeat("order line\n", 200))
+	opt := &RequestOption{
+		CompressRequest:    true,
+		RetryCount:         2,
+		RetryInterval:      time.Nanosecond,
+		TreatNon2xxAsError: true,
+	}
+
+	_, err := NewClient(testConfig(), srv.URL).Put(context.Background(), "/", body, opt)
+
+	require.NoError(t, err)
+	got := seen()
+	require.Len(t, got, 3)
+	for _, r := range got {
+		assert.Equal(t, "gzip", r.encoding)
+		assert.Equal(t, got[0].body, r.body)
+	}
+	assert.Equal(t, body, gunzip(t, got[2].body))
+}
+
+func TestCompressRequestDoesNotMutateCallerHeaders(t *testing.T) {
+	srv, _ := bodyServer(t, 0)
+	headers := map[string]string{"X-Request-ID": "r-1"}
+	opt := &RequestOption{CompressRequest: true, Headers: headers}
+
+	_, err := NewClient(testConfig(), srv.URL).Post(context.Background(), "/", bytes.Repeat([]byte("a"), 2048), opt)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Request-ID": "r-1"}, headers)
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"order-system/pkg/platform/logger"
+)
+
+// redactedValue replaces sensitive header values in debug logs
+const redactedValue = "[REDACTED]"
+
+// defaultRedactHeaders are masked when no redaction list is configured
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// debugEnabled reports whether request and response details are logged
+func (c *defaultClient) debugEnabled() bool {
+	return c.log != nil && c.config.HTTP.Debug
+}
+
+// logRequest logs the outgoing request of a single attempt
+func (c *defaultClient) logRequest(ctx context.Context, req *http.Request, body []byte, opt *RequestOption) {
+	if !c.debugEnabled() {
+		return
+	}
+	c.log.Debug(ctx, "http request",
+		logger.Field{Key: "method", Value: req.Method},
+		logger.Field{Key: "url", Value: req.URL.String()},
+		logger.Field{Key: "headers", Value: c.redactHeaders(req.Header)},
+		logger.Field{Key: "body", Value: string(capBody(body, opt.MaxBodySize))},
+	)
+}
+
+// logResponse logs the response received for a single attempt
+func (c *defaultClient) logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, duration time.Duration) {
+	if !c.debugEnabled() {
+		return
+	}
+	c.log.Debug(ctx, "http response",
+		logger.Field{Key: "method", Value: req.Method},
+		logger.Field{Key: "url", Value: req.URL.String()},
+		logger.Field{Key: "status", Value: resp.StatusCode},
+		logger.Field{Key: "headers", Value: c.redactHeaders(resp.Header)},
+		logger.Field{Key: "body", Value: string(body)},
+		logger.Field{Key: "duration", Value: duration.String()},
+	)
+}
+
+// logError logs a transport failure for a single attempt
+func (c *defaultClient) logError(ctx context.Context, req *http.Request, err error) {
+	if !c.debugEnabled() {
+		return
+	}
+	c.log.Debug(ctx, "http request failed",
+		logger.Field{Key: "method", Value: req.Method},
+		logger.Field{Key: "url", Value: req.URL.String()},
+		logger.Field{Key: "error", Value: err.Error()},
+	)
+}
+
+// redactHeaders returns a copy of h with sensitive values masked
+func (c *defaultClient) redactHeaders(h http.Header) map[string][]string {
+	names := c.config.HTTP.RedactHeaders
+	if len(names) == 0 {
+		names = defaultRedactHeaders
+	}
+	sensitive := make(map[string]bool, len(names))
+	for _, name := range names {
+		sensitive[http.CanonicalHeaderKey(name)] = true
+	}
+
+	out := make(map[string][]string, len(h))
+	for k, v := range h {
+		if sensitive[http.CanonicalHeaderKey(k)] {
+			out[k] = []string{redactedValue}
+			continue
+		}
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// capBody truncates body to at most max bytes; a non-positive max keeps it whole
+func capBody(body []byte, max int64) []byte {
+	if max > 0 && int64(len(body)) > max {
+		return body[:max]
+	}
+	return body
+}
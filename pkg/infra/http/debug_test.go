@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/platform/logger"
+)
+
+// debugEntry is a debug log call as seen by recordingLogger
+type debugEntry struct {
+	msg    string
+	fields map[string]interface{}
+}
+
+// recordingLogger records debug entries and discards everything else
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []debugEntry
+}
+
+func (l *recordingLogger) logged() []debugEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]debugEntry(nil), l.entries...)
+}
+
+func (l *recordingLogger) Debug(ctx context.Context, msg string, fields ...logger.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	l.entries = append(l.entries, debugEntry{msg: msg, fields: m})
+}
+
+func (l *recordingLogger) Info(ctx context.Context, msg string, fields ...logger.Field)             {}
+func (l *recordingLogger) Warn(ctx context.Context, msg string, fields ...logger.Field)             {}
+func (l *recordingLogger) Error(ctx context.Context, msg string, err error, fields ...logger.Field) {}
+func (l *recordingLogger) WithComponent(component string) logger.Logger                             { return l }
+func (l *recordingLogger) WithComponentFields(component string, fields ...logger.Field) logger.Logger {
+	return l
+}
+func (l *recordingLogger) WithFields(fields ...logger.Field) logger.Logger { return l }
+func (l *recordingLogger) Close() error                                    { return nil }
+
+// echoServer responds to every request with its body and a session cookie
+func echoServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDebugLogsBodiesAndRedactsHeaders(t *testing.T) {
+	srv := echoServer(t, http.StatusOK)
+	cfg := testConfig()
+	cfg.HTTP.Debug = true
+	log := &recordingLogger{}
+	c := NewClient(cfg, srv.URL, WithLogger(log))
+
+	_, err := c.Post(context.Background(), "/orders", []byte(`{"id":1}`), &RequestOption{
+		Headers: map[string]string{"Authorization": "Bearer token", "X-Tenant": "acme"},
+	})
+	require.NoError(t, err)
+
+	entries := log.logged()
+	require.Len(t, entries, 2)
+	req, resp := entries[0], entries[1]
+	assert.Equal(t, "http request", req.msg)
+	assert.Equal(t, `{"id":1}`, req.fields["body"])
+	reqHeaders := req.fields["headers"].(map[string][]string)
+	assert.Equal(t, []string{redactedValue}, reqHeaders["Authorization"])
+	assert.Equal(t, []string{"acme"}, reqHeaders["X-Tenant"])
+
+	assert.Equal(t, "http response", resp.msg)
+	assert.Equal(t, http.StatusOK, resp.fields["status"])
+	assert.Equal(t, `{"id":1}`, resp.fields["body"])
+	respHeaders := resp.fields["headers"].(map[string][]string)
+	assert.Equal(t, []string{redactedValue}, respHeaders["Set-Cookie"])
+	assert.Equal(t, []string{"req-1"}, respHeaders["X-Request-Id"])
+}
+
+func TestDebugLogsEveryAttemptAndCapsBody(t *testing.T) {
+	srv := echoServer(t, http.StatusServiceUnavailable)
+	cfg := testConfig()
+	cfg.HTTP.Debug = true
+	cfg.HTTP.RedactHeaders = []string{"X-Tenant"}
+	log := &recordingLogger{}
+	c := NewClient(cfg, srv.URL, WithLogger(log))
+	opt := failingOpt(1)
+	opt.MaxBodySize = 4
+	opt.Headers = map[string]string{"X-Tenant": "acme"}
+
+	_, err := c.Put(context.Background(), "/orders/1", []byte("0123456789"), opt)
+	require.Error(t, err)
+
+	var requests []debugEntry
+	for _, e := range log.logged() {
+		if e.msg == "http request" {
+			requests = append(requests, e)
+		}
+	}
+	require.Len(t, requests, 2, "one per attempt")
+	for _, e := range requests {
+		assert.Equal(t, "0123", e.fields["body"])
+		assert.Equal(t, []string{redactedValue}, e.fields["headers"].(map[string][]string)["X-Tenant"])
+	}
+}
+
+func TestDebugOffByDefault(t *testing.T) {
+	srv := echoServer(t, http.StatusOK)
+	log := &recordingLogger{}
+	c := NewClient(testConfig(), srv.URL, WithLogger(log))
+
+	_, err := c.Get(context.Background(), "/orders", nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, log.logged())
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+
+	"order-system/pkg/platform/logger"
+)
+
+// Option configures a client created by NewClient
+type Option func(*ClientOptions)
+
+// WithLogger logs request and response details through log when
+// HTTP.Debug is set
+func WithLogger(log logger.Logger) Option {
+	return func(o *ClientOptions) {
+		o.Logger = log
+	}
+}
+
+// WithTransport replaces the client's own http.Transport
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *ClientOptions) {
+		o.Transport = rt
+	}
+}
+
+// WithHeaderFromContext sends the value stored under key in the request
+// context as header
+func WithHeaderFromContext(key interface{}, header string) Option {
+	return func(o *ClientOptions) {
+		if o.HeaderFromContext == nil {
+			o.HeaderFromContext = make(map[interface{}]string)
+		}
+		o.HeaderFromContext[key] = header
+	}
+}
+
+// WithTokenSource sends a bearer token from src with every request and
+// uses refresh, when non-nil, to obtain a new token after a 401
+func WithTokenSource(src, refresh TokenSource) Option {
+	return func(o *ClientOptions) {
+		o.TokenSource = src
+		o.RefreshToken = refresh
+	}
+}
+
+// WithAdaptiveThrottling skips retries, returning a *ThrottledError, with a
+// probability that rises as recent attempts exceed ratio times those the
+// downstream accepted
+func WithAdaptiveThrottling(ratio float64) Option {
+	return func(o *ClientOptions) {
+		o.ThrottleRatio = ratio
+	}
+}
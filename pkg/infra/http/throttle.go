@@ -0,0 +1,96 @@
+package http
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Adaptive throttling tracks attempts over throttleWindow, split into
+// throttleBuckets buckets that expire one at a time
+const (
+	throttleWindow  = 2 * time.Minute
+	throttleBuckets = 12
+)
+
+// throttleBucket counts the attempts and accepts of one slice of the window
+type throttleBucket struct {
+	start    time.Time
+	requests float64
+	accepts  float64
+}
+
+// adaptiveThrottle implements client-side adaptive throttling: once the
+// attempts over the window exceed ratio times the attempts the downstream
+// accepted, retries are rejected with probability
+// (requests - ratio*accepts) / (requests + 1)
+type adaptiveThrottle struct {
+	mu      sync.Mutex
+	ratio   float64
+	buckets [throttleBuckets]throttleBucket
+	now     func() time.Time
+	rand    func() float64
+}
+
+// newAdaptiveThrottle creates a throttle, or nil if ratio disables it
+func newAdaptiveThrottle(ratio float64) *adaptiveThrottle {
+	if ratio <= 0 {
+		return nil
+	}
+	return &adaptiveThrottle{
+		ratio: ratio,
+		now:   time.Now,
+		rand:  rand.Float64,
+	}
+}
+
+// record counts an attempt, accepted if the downstream handled it rather
+// than failing in a way worth retrying
+func (t *adaptiveThrottle) record(accepted bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.bucket(t.now())
+	b.requests++
+	if accepted {
+		b.accepts++
+	}
+}
+
+// reject reports whether a retry should be skipped
+func (t *adaptiveThrottle) reject() bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var requests, accepts float64
+	for _, b := range t.buckets {
+		if now.Sub(b.start) < throttleWindow {
+			requests += b.requests
+			accepts += b.accepts
+		}
+	}
+
+	p := (requests - t.ratio*accepts) / (requests + 1)
+	return p > 0 && t.rand() < p
+}
+
+// bucket returns the bucket covering now, resetting it if it last held an
+// earlier slice of time
+func (t *adaptiveThrottle) bucket(now time.Time) *throttleBucket {
+	width := throttleWindow / throttleBuckets
+	start := now.Truncate(width)
+	b := &t.buckets[(start.UnixNano()/int64(width))%throttleBuckets]
+	if !b.start.Equal(start) {
+		*b = throttleBucket{start: start}
+	}
+	return b
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// throttleWithClock returns a throttle reading time from the returned
+// clock that rejects whenever the rejection probability is positive
+func throttleWithClock(ratio float64) (*adaptiveThrottle, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	th := newAdaptiveThrottle(ratio)
+	th.now = func() time.Time { return now }
+	th.rand = func() float64 { return 0 }
+	return th, &now
+}
+
+func TestAdaptiveThrottleRejectsOnlyAboveRatio(t *testing.T) {
+	th, _ := throttleWithClock(2)
+	for i := 0; i < 10; i++ {
+		th.record(true)
+	}
+	assert.False(t, th.reject(), "healthy downstream")
+
+	for i := 0; i < 10; i++ {
+		th.record(false)
+	}
+	assert.False(t, th.reject(), "20 requests is not above 2 x 10 accepts")
+
+	th.record(false)
+	assert.True(t, th.reject())
+}
+
+func TestAdaptiveThrottleForgetsExpiredAttempts(t *testing.T) {
+	th, now := throttleWithClock(2)
+	for i := 0; i < 50; i++ {
+		th.record(false)
+	}
+	require.True(t, th.reject())
+
+	*now = now.Add(throttleWindow)
+	assert.False(t, th.reject())
+}
+
+func TestAdaptiveThrottleRejectsWithProbability(t *testing.T) {
+	th, _ := throttleWithClock(2)
+	for i := 0; i < 9; i++ {
+		th.record(false)
+	}
+	// p = (9 - 0) / (9 + 1)
+	th.rand = func() float64 { return 0.89 }
+	assert.True(t, th.reject())
+	th.rand = func() float64 { return 0.9 }
+	assert.False(t, th.reject())
+}
+
+func TestAdaptiveThrottleDisabled(t *testing.T) {
+	th := newAdaptiveThrottle(0)
+	assert.Nil(t, th)
+	th.record(false)
+	assert.False(t, th.reject())
+}
+
+// flakyServer fails requests with 503 while its counter is positive,
+// decrementing it each time, and counts every request
+func flakyServer(t *testing.T) (srv *httptest.Server, failures, hits *int64) {
+	t.Helper()
+	failures, hits = new(int64), new(int64)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(hits, 1)
+		if atomic.AddInt64(failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, failures, hits
+}
+
+func TestAdaptiveThrottlingSuppressesThenRestoresRetries(t *testing.T) {
+	srv, failures, hits := flakyServer(t)
+	c := NewClient(testConfig(), srv.URL, WithAdaptiveThrottling(2)).(*defaultClient)
+	c.throttle.rand = func() float64 { return 0 }
+
+	// An outage: once failures dominate, retries are skipped
+	atomic.StoreInt64(failures, 1000)
+	var throttled *ThrottledError
+	for i := 0; i < 10 && throttled == nil; i++ {
+		_, err := c.Get(context.Background(), "/", failingOpt(3))
+		errors.As(err, &throttled)
+	}
+	require.NotNil(t, throttled, "retries were never throttled")
+	before := atomic.LoadInt64(hits)
+	_, err := c.Get(context.Background(), "/", failingOpt(3))
+	assert.ErrorAs(t, err, &throttled)
+	assert.Equal(t, int64(1), atomic.LoadInt64(hits)-before, "a throttled request makes a single attempt")
+	var httpErr *Error
+	assert.ErrorAs(t, err, &httpErr, "the last attempt's error is kept")
+
+	// Recovery: enough successes bring the ratio back under the limit
+	atomic.StoreInt64(failures, 0)
+	for n := 2 * atomic.LoadInt64(hits); n > 0; n-- {
+		_, err := c.Get(context.Background(), "/", nil)
+		require.NoError(t, err)
+	}
+
+	atomic.StoreInt64(failures, 1)
+	before = atomic.LoadInt64(hits)
+	_, err = c.Get(context.Background(), "/", failingOpt(3))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), atomic.LoadInt64(hits)-before, "the failed attempt was retried")
+}
+
+func TestRetriesUnthrottledByDefault(t *testing.T) {
+	srv, hits := countingServer(t, http.StatusServiceUnavailable)
+	c := NewClient(testConfig(), srv.URL)
+
+	for i := 0; i < 20; i++ {
+		_, err := c.Get(context.Background(), "/", failingOpt(2))
+		var throttled *ThrottledError
+		assert.False(t, errors.As(err, &throttled))
+	}
+	assert.Equal(t, int64(60), atomic.LoadInt64(hits))
+}
@@ -2,7 +2,23 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
 	"time"
+
+	"order-system/pkg/platform/logger"
+)
+
+// Protocol modes for the client transport
+const (
+	// ProtocolHTTP1 forces HTTP/1.1
+	ProtocolHTTP1 = "http1"
+	// ProtocolHTTP2 forces HTTP/2 over TLS
+	ProtocolHTTP2 = "http2"
+	// ProtocolH2C uses HTTP/2 with prior knowledge over cleartext
+	ProtocolH2C = "h2c"
 )
 
 // RequestOption represents options for a request
@@ -12,6 +28,54 @@ type RequestOption struct {
 	RetryInterval time.Duration
 	MaxBodySize   int64
 	Headers       map[string]string
+	// RetryMultiplier grows RetryInterval after each retry; values below 1
+	// keep it constant
+	RetryMultiplier float64
+	// MaxRetryInterval caps the grown interval; zero means no cap
+	MaxRetryInterval time.Duration
+	// CompressRequest gzips bodies of at least CompressThreshold bytes
+	// and sets Content-Encoding: gzip. Retries resend the same compressed
+	// body.
+	CompressRequest bool
+	// CompressThreshold is the smallest body size compressed; zero uses
+	// 1 KiB
+	CompressThreshold int
+	// TreatNon2xxAsError returns an *Error carrying the status code and a
+	// snippet of the body for non-2xx responses instead of the Response
+	TreatNon2xxAsError bool
+	// SplitDeadline caps each attempt to an equal share of the time left
+	// before the context deadline, so one slow attempt can't consume the
+	// whole budget and leave nothing for retries
+	SplitDeadline bool
+	// OnRetry is called before each retry wait with the number of the
+	// attempt that failed (starting at 1), its error and the wait duration
+	OnRetry func(attempt int, err error, nextDelay time.Duration)
+	// RetryNonIdempotent allows retrying methods other than GET, HEAD, PUT
+	// and DELETE; only set it when the request is safe to repeat
+	RetryNonIdempotent bool
+}
+
+// ClientOptions configures optional client behaviour
+type ClientOptions struct {
+	// Logger receives request and response details when HTTP.Debug is set
+	Logger logger.Logger
+	// HeaderFromContext maps context keys to the header each request
+	// sends with the key's value. Headers in RequestOption take precedence.
+	HeaderFromContext map[interface{}]string
+	// Transport replaces the client's own http.Transport when set, so the
+	// connection and protocol settings in the config don't apply
+	Transport http.RoundTripper
+	// TokenSource supplies the bearer token sent with every request
+	TokenSource TokenSource
+	// RefreshToken obtains a new token after a 401. Concurrent 401s share
+	// one refresh, each request is retried once with the new token, and
+	// later requests send it instead of TokenSource's until the next 401.
+	RefreshToken TokenSource
+	// ThrottleRatio enables adaptive throttling of retries: once recent
+	// attempts exceed ThrottleRatio times those the downstream accepted,
+	// retries are skipped with rising probability. Typical values are
+	// 1.5 to 2; zero disables it.
+	ThrottleRatio float64
 }
 
 // Response represents an HTTP response
@@ -22,11 +86,57 @@ type Response struct {
 	Duration   time.Duration
 }
 
+// Header returns the first value of the header key, matched without regard
+// to case, or "" if it is absent
+func (r *Response) Header(key string) string {
+	if values := r.HeaderValues(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// HeaderValues returns every value of the header key, matched without
+// regard to case. Values under the canonical key come first, followed by
+// those under other casings in key order.
+func (r *Response) HeaderValues(key string) []string {
+	canonical := textproto.CanonicalMIMEHeaderKey(key)
+	values := r.Headers[canonical]
+
+	var others []string
+	for k := range r.Headers {
+		if k != canonical && strings.EqualFold(k, canonical) {
+			others = append(others, k)
+		}
+	}
+	if len(others) == 0 {
+		return values
+	}
+	sort.Strings(others)
+
+	merged := append([]string(nil), values...)
+	for _, k := range others {
+		merged = append(merged, r.Headers[k]...)
+	}
+	return merged
+}
+
+// ContentType returns the Content-Type header
+func (r *Response) ContentType() string {
+	return r.Header("Content-Type")
+}
+
 // Error represents an HTTP error
 type Error struct {
 	StatusCode int
 	Message    string
 	Cause      error
+	// Body holds up to maxErrorBodySize bytes of the response body
+	Body []byte
+	// Attempts is the number of attempts made, including retries
+	Attempts int
+	// AttemptErrors holds the error of each attempt in order; the last
+	// one is a copy of this error without Attempts or AttemptErrors
+	AttemptErrors []error
 }
 
 func (e *Error) Error() string {
@@ -36,6 +146,39 @@ func (e *Error) Error() string {
 	return e.Message
 }
 
+// RetryCancelledError is returned when the context ends while waiting to
+// retry. It unwraps to both the context error, distinguishing a caller
+// cancel from a deadline, and the error of the last attempt.
+type RetryCancelledError struct {
+	Err     error
+	LastErr error
+}
+
+func (e *RetryCancelledError) Error() string {
+	return "retry wait cancelled: " + e.Err.Error() + " (last attempt: " + e.LastErr.Error() + ")"
+}
+
+// Unwrap returns the context error and the last attempt's error
+func (e *RetryCancelledError) Unwrap() []error {
+	return []error{e.Err, e.LastErr}
+}
+
+// ThrottledError is returned when adaptive throttling skips a retry
+// because the downstream has been failing most recent attempts. It unwraps
+// to the error of the last attempt.
+type ThrottledError struct {
+	LastErr error
+}
+
+func (e *ThrottledError) Error() string {
+	return "retry throttled: " + e.LastErr.Error()
+}
+
+// Unwrap returns the last attempt's error
+func (e *ThrottledError) Unwrap() error {
+	return e.LastErr
+}
+
 // Client interface defines the HTTP client behavior
 type Client interface {
 	Get(ctx context.Context, url string, opt *RequestOption) (*Response, error)
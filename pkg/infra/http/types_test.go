@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseHeaderIgnoresCase(t *testing.T) {
+	resp := &Response{Headers: map[string][]string{
+		"Content-Type": {"application/json"},
+		"X-Request-Id": {"r-1"},
+	}}
+
+	for _, key := range []string{"content-type", "CONTENT-TYPE", "Content-Type", "cOnTeNt-TyPe"} {
+		assert.Equal(t, "application/json", resp.Header(key), key)
+	}
+	assert.Equal(t, "r-1", resp.Header("x-request-id"))
+	assert.Equal(t, "application/json", resp.ContentType())
+	assert.Empty(t, resp.Header("X-Missing"))
+	assert.Nil(t, resp.HeaderValues("X-Missing"))
+}
+
+func TestResponseHeaderValuesMergesCasings(t *testing.T) {
+	resp := &Response{Headers: map[string][]string{
+		"x-tag": {"b"},
+		"X-Tag": {"a"},
+		"X-TAG": {"c"},
+	}}
+
+	assert.Equal(t, []string{"a", "c", "b"}, resp.HeaderValues("x-tag"), "canonical first, then other casings by key")
+	assert.Equal(t, "a", resp.Header("X-TAG"))
+}
+
+func TestResponseHeaderFromServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/csv")
+		w.Header().Add("X-Region", "eu")
+		w.Header().Add("x-region", "us")
+	}))
+	t.Cleanup(srv.Close)
+
+	resp, err := NewClient(testConfig(), srv.URL).Get(context.Background(), "/", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", resp.ContentType())
+	assert.Equal(t, []string{"eu", "us"}, resp.HeaderValues("X-REGION"))
+}
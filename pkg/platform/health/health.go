@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Checker aggregates named health checks across subsystems
+type Checker struct {
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+}
+
+// NewChecker creates a new health checker
+func NewChecker() *Checker {
+	return &Checker{
+		checks: make(map[string]CheckFunc),
+	}
+}
+
+// Register adds a named check
+func (c *Checker) Register(name string, check CheckFunc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.checks[name]; exists {
+		return fmt.Errorf("health check %s already registered", name)
+	}
+	c.checks[name] = check
+	return nil
+}
+
+// Check runs every check concurrently and reports the aggregate status,
+// which is down if any check fails
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		name, check := name, check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run executes a single check, treating a panic as a failure
+func run(ctx context.Context, check CheckFunc) (result CheckResult) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result = CheckResult{
+				Status:   StatusDown,
+				Error:    fmt.Sprintf("panic: %v", r),
+				Duration: time.Since(start),
+			}
+		}
+	}()
+
+	if err := check(ctx); err != nil {
+		return CheckResult{
+			Status:   StatusDown,
+			Error:    err.Error(),
+			Duration: time.Since(start),
+		}
+	}
+	return CheckResult{
+		Status:   StatusUp,
+		Duration: time.Since(start),
+	}
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAggregatesPassingAndFailingChecks(t *testing.T) {
+	c := NewChecker()
+	require.NoError(t, c.Register("database", func(ctx context.Context) error { return nil }))
+	require.NoError(t, c.Register("payments", func(ctx context.Context) error { return errors.New("circuit open") }))
+
+	report := c.Check(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, StatusUp, report.Checks["database"].Status)
+	assert.Empty(t, report.Checks["database"].Error)
+	assert.Equal(t, StatusDown, report.Checks["payments"].Status)
+	assert.Equal(t, "circuit open", report.Checks["payments"].Error)
+}
+
+func TestCheckUpWhenEveryCheckPasses(t *testing.T) {
+	c := NewChecker()
+	require.NoError(t, c.Register("database", func(ctx context.Context) error { return nil }))
+	require.NoError(t, c.Register("metrics", func(ctx context.Context) error { return nil }))
+
+	report := c.Check(context.Background())
+
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Len(t, report.Checks, 2)
+}
+
+func TestCheckTreatsPanicAsFailure(t *testing.T) {
+	c := NewChecker()
+	require.NoError(t, c.Register("cache", func(ctx context.Context) error { panic("nil client") }))
+
+	report := c.Check(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "panic: nil client", report.Checks["cache"].Error)
+}
+
+func TestRegisterRejectsDuplicateName(t *testing.T) {
+	c := NewChecker()
+	require.NoError(t, c.Register("database", func(ctx context.Context) error { return nil }))
+
+	assert.Error(t, c.Register("database", func(ctx context.Context) error { return nil }))
+}
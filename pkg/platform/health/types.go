@@ -0,0 +1,32 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Status represents the health status of a check or of the whole service
+type Status string
+
+const (
+	// StatusUp means the check passed
+	StatusUp Status = "up"
+	// StatusDown means the check failed
+	StatusDown Status = "down"
+)
+
+// CheckFunc checks a single subsystem, returning an error if it is unhealthy
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult represents the outcome of a single check
+type CheckResult struct {
+	Status   Status        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report represents the aggregate health with a per-check breakdown
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPooledBuffer keeps unusually large entries from pinning memory in
+// the buffer pool
+const maxPooledBuffer = 64 << 10
+
+// bufferPool reuses entry buffers across log calls
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns buf to the pool
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// appendEntryJSON appends the JSON form of entry with nested fields to
+// buf. The output matches json.Marshal of entryToMap: keys are sorted and
+// a repeated field key keeps its last value. Entry fields are reordered.
+func appendEntryJSON(buf *bytes.Buffer, entry Entry) error {
+	buf.WriteByte('{')
+	if entry.Component != "" {
+		buf.WriteString(`"component":`)
+		appendJSONString(buf, entry.Component)
+		buf.WriteByte(',')
+	}
+	if entry.Error != nil {
+		buf.WriteString(`"error":`)
+		if err := appendJSONValue(buf, errorToMap(entry.Error)); err != nil {
+			return err
+		}
+		buf.WriteByte(',')
+	}
+
+	buf.WriteString(`"fields":{`)
+	sort.SliceStable(entry.Fields, func(i, j int) bool {
+		return entry.Fields[i].Key < entry.Fields[j].Key
+	})
+	first := true
+	for i, f := range entry.Fields {
+		if i+1 < len(entry.Fields) && entry.Fields[i+1].Key == f.Key {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		appendJSONString(buf, f.Key)
+		buf.WriteByte(':')
+		if err := appendJSONValue(buf, f.Value); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(`},"level":`)
+	appendJSONString(buf, entry.Level.String())
+	buf.WriteString(`,"msg":`)
+	appendJSONString(buf, entry.Message)
+	if entry.SpanID != "" {
+		buf.WriteString(`,"span_id":`)
+		appendJSONString(buf, entry.SpanID)
+	}
+	buf.WriteString(`,"time":"`)
+	buf.Write(entry.Time.AppendFormat(buf.AvailableBuffer(), time.RFC3339))
+	buf.WriteByte('"')
+	if entry.TraceID != "" {
+		buf.WriteString(`,"trace_id":`)
+		appendJSONString(buf, entry.TraceID)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// appendJSONValue appends v as JSON, encoding common types directly and
+// falling back to json.Marshal for the rest
+func appendJSONValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		appendJSONString(buf, v)
+	case bool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), v))
+	case int:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), v, 10))
+	case int32:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+	case uint:
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), uint64(v), 10))
+	case uint64:
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), v, 10))
+	case uint32:
+		buf.Write(strconv.AppendUint(buf.AvailableBuffer(), uint64(v), 10))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// hexDigits are used to escape control characters
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, escaping it the same way
+// encoding/json does, including HTML-sensitive characters
+func appendJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[b>>4])
+				buf.WriteByte(hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
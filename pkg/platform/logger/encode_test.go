@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+func TestAppendEntryJSONMatchesMapEncoding(t *testing.T) {
+	l, _ := newBufferLogger(t, nil)
+	entry := Entry{
+		Level:     Warn,
+		Message:   "quote \" <tag> &   tab\t",
+		Time:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Component: "orders",
+		TraceID:   "trace-1",
+		SpanID:    "span-1",
+		Error:     errors.New("boom"),
+		Fields: []Field{
+			{Key: "user", Value: "alice"},
+			{Key: "count", Value: 3},
+			{Key: "user", Value: "bob"},
+			{Key: "ok", Value: true},
+			{Key: "nested", Value: map[string]int{"a": 1}},
+			{Key: "nothing", Value: nil},
+		},
+	}
+	want, err := json.Marshal(l.entryToMap(entry))
+	require.NoError(t, err)
+
+	// appendEntryJSON reorders the fields, so it gets its own copy
+	entry.Fields = append([]Field(nil), entry.Fields...)
+	var buf bytes.Buffer
+	require.NoError(t, appendEntryJSON(&buf, entry))
+
+	assert.Equal(t, string(want), buf.String())
+}
+
+func TestSuppressedLevelDoesNotAllocate(t *testing.T) {
+	l := discardLogger(t, "info")
+	ctx := context.Background()
+	fields := []Field{{Key: "order_id", Value: "o-1"}}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Debug(ctx, "suppressed", fields...)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestPooledPathAllocatesLessThanMapPath(t *testing.T) {
+	pooled := discardLogger(t, "info")
+	mapped := discardLogger(t, "info", WithMarshaler(json.Marshal))
+	ctx := context.Background()
+	fields := []Field{{Key: "order_id", Value: "o-1"}, {Key: "amount", Value: 42}}
+
+	pooledAllocs := testing.AllocsPerRun(100, func() {
+		pooled.Info(ctx, "order placed", fields...)
+	})
+	mappedAllocs := testing.AllocsPerRun(100, func() {
+		mapped.Info(ctx, "order placed", fields...)
+	})
+	assert.Less(t, pooledAllocs, mappedAllocs)
+}
+
+// discardLogger returns a JSON logger at level that writes to io.Discard
+func discardLogger(tb testing.TB, level string, opts ...Option) Logger {
+	tb.Helper()
+	tb.Setenv(LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = level
+	cfg.Logger.Output = "stdout"
+	all := []Option{
+		WithLevelOutput(Debug, io.Discard),
+		WithLevelOutput(Info, io.Discard),
+		WithLevelOutput(Warn, io.Discard),
+		WithLevelOutput(Error, io.Discard),
+	}
+	l, err := New(cfg, append(all, opts...)...)
+	require.NoError(tb, err)
+	return l
+}
+
+// benchmarkInfo logs a typical info entry b.N times through l
+func benchmarkInfo(b *testing.B, l Logger) {
+	ctx := context.Background()
+	l = l.WithComponent("orders").WithFields(Field{Key: "service", Value: "api"})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Info(ctx, "order placed",
+			Field{Key: "order_id", Value: "o-1"},
+			Field{Key: "amount", Value: 42},
+		)
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	benchmarkInfo(b, discardLogger(b, "info"))
+}
+
+func BenchmarkInfoMapEncoding(b *testing.B) {
+	benchmarkInfo(b, discardLogger(b, "info", WithMarshaler(json.Marshal)))
+}
+
+func BenchmarkDebugSuppressed(b *testing.B) {
+	l := discardLogger(b, "info")
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug(ctx, "suppressed", Field{Key: "order_id", Value: "o-1"})
+	}
+}
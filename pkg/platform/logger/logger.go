@@ -3,49 +3,185 @@ package logger
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
 )
 
 // defaultLogger implements the Logger interface
 type defaultLogger struct {
-	mu        sync.Mutex
-	out       io.Writer
+	out       *lockedWriter
+	outputs   map[Level]*lockedWriter
 	level     Level
 	component string
 	fields    []Field
+	flatten   bool
+	text      bool
+	maxField  int
+	ctxKeys   []ContextKey
+	marshal   MarshalFunc
 }
 
-// New creates a new logger
-func New(cfg *config.Config) (Logger, error) {
-	level, err := parseLevel(cfg.Logger.Level)
+// lockedWriter serializes writes to a writer shared by derived loggers
+type lockedWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	owned  bool // opened by the logger, so closed by Close
+	sync   bool // flush and fsync after every write
+	closed bool
+}
+
+// Close closes the underlying writer if the logger opened it. Writes after
+// Close are dropped, and closing more than once is a no-op.
+func (lw *lockedWriter) Close() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.closed {
+		return nil
+	}
+	lw.closed = true
+
+	if c, ok := lw.w.(io.Closer); ok && lw.owned {
+		return c.Close()
+	}
+	return nil
+}
+
+// syncer is implemented by outputs that can commit written data to stable
+// storage, such as *os.File
+type syncer interface {
+	Sync() error
+}
+
+// flusher is implemented by buffered outputs
+type flusher interface {
+	Flush() error
+}
+
+// commit flushes buffered output and fsyncs it when sync is enabled.
+// Callers must hold lw.mu.
+func (lw *lockedWriter) commit(n int, err error) (int, error) {
+	if err != nil || !lw.sync {
+		return n, err
+	}
+	if f, ok := lw.w.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			return n, err
+		}
+	}
+	if s, ok := lw.w.(syncer); ok {
+		if err := s.Sync(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// levelWriter is implemented by outputs that record the entry level
+// themselves, such as syslog
+type levelWriter interface {
+	WriteLevel(level Level, p []byte) (int, error)
+}
+
+// Write implements io.Writer
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.closed {
+		return 0, nil
+	}
+	return lw.commit(lw.w.Write(p))
+}
+
+// WriteLevel writes an entry of the given level
+func (lw *lockedWriter) WriteLevel(level Level, p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if lw.closed {
+		return 0, nil
+	}
+	if w, ok := lw.w.(levelWriter); ok {
+		return lw.commit(w.WriteLevel(level, p))
+	}
+	return lw.commit(lw.w.Write(p))
+}
+
+// New creates a new logger configured by opts
+func New(cfg *config.Config, opts ...Option) (Logger, error) {
+	level, err := parseLevel(strings.ToLower(cfg.Logger.Level))
 	if err != nil {
 		return nil, err
 	}
+	envLevel, envErr := levelFromEnv()
+	if envErr == nil {
+		level = envLevel
+	}
 
 	var out io.Writer
+	owned := true
+	sync := false
 	switch cfg.Logger.Output {
 	case "stdout":
 		out = os.Stdout
+		owned = false
 	case "stderr":
 		out = os.Stderr
+		owned = false
 	default:
+		if isSyslogOutput(cfg.Logger.Output) {
+			out, err = openSyslog(cfg.Logger.Output)
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
 		file, err := os.OpenFile(cfg.Logger.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open log file: %w", err)
 		}
 		out = file
+		sync = cfg.Logger.Sync
+	}
+
+	l := &defaultLogger{
+		out:     &lockedWriter{w: out, owned: owned, sync: sync},
+		level:   level,
+		flatten: cfg.Logger.FlattenFields,
+		text:    cfg.Logger.Format == "text",
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	// Warn regardless of the level so a mistyped override is noticed
+	if envErr != nil && envErr != errLevelEnvUnset {
+		l.log(context.Background(), Warn, "ignoring "+LevelEnv, envErr)
+	}
+	return l, nil
+}
 
-	return &defaultLogger{
-		out:   out,
-		level: level,
-	}, nil
+// LevelEnv names the environment variable that overrides the configured
+// log level
+const LevelEnv = "LOG_LEVEL"
+
+// errLevelEnvUnset reports that LevelEnv is not set
+var errLevelEnvUnset = stderrors.New(LevelEnv + " not set")
+
+// levelFromEnv parses the level set in LevelEnv
+func levelFromEnv() (Level, error) {
+	value, ok := os.LookupEnv(LevelEnv)
+	if !ok || value == "" {
+		return Info, errLevelEnvUnset
+	}
+	return parseLevel(strings.ToLower(value))
 }
 
 // parseLevel parses the log level string
@@ -94,33 +230,83 @@ func (l *defaultLogger) Error(ctx context.Context, msg string, err error, fields
 
 // WithComponent implements Logger.WithComponent
 func (l *defaultLogger) WithComponent(component string) Logger {
-	return &defaultLogger{
-		out:       l.out,
-		level:     l.level,
-		component: component,
-		fields:    l.fields,
-	}
+	derived := l.clone()
+	derived.component = component
+	return derived
+}
+
+// WithComponentFields implements Logger.WithComponentFields
+func (l *defaultLogger) WithComponentFields(component string, fields ...Field) Logger {
+	derived := l.clone()
+	derived.component = component
+	derived.fields = mergeFields(l.fields, fields)
+	return derived
 }
 
 // WithFields implements Logger.WithFields
 func (l *defaultLogger) WithFields(fields ...Field) Logger {
-	return &defaultLogger{
-		out:       l.out,
-		level:     l.level,
-		component: l.component,
-		fields:    append(l.fields, fields...),
+	derived := l.clone()
+	derived.fields = mergeFields(l.fields, fields)
+	return derived
+}
+
+// Close flushes and closes the output if the logger opened it; later
+// writes are dropped. Derived loggers share the output, so closing any one
+// of them closes it for all.
+func (l *defaultLogger) Close() error {
+	return l.out.Close()
+}
+
+// clone returns a shallow copy of the logger for deriving a new one
+func (l *defaultLogger) clone() *defaultLogger {
+	derived := *l
+	return &derived
+}
+
+// writerFor returns the locked writer already wrapping w, so a writer used
+// for several levels is guarded by a single mutex. A nil w falls back to
+// the default output.
+func (l *defaultLogger) writerFor(w io.Writer) *lockedWriter {
+	if w == nil {
+		return l.out
 	}
+	if reflect.TypeOf(w).Comparable() {
+		if l.out.w == w {
+			return l.out
+		}
+		for _, out := range l.outputs {
+			if out.w == w {
+				return out
+			}
+		}
+	}
+	return &lockedWriter{w: w}
+}
+
+// output returns the writer for the given level
+func (l *defaultLogger) output(level Level) *lockedWriter {
+	if out, ok := l.outputs[level]; ok {
+		return out
+	}
+	return l.out
 }
 
 // log writes a log entry
 func (l *defaultLogger) log(ctx context.Context, level Level, msg string, err error, fields ...Field) {
+	base := l.fields
+	if len(l.ctxKeys) > 0 {
+		base = mergeFields(base, l.contextFields(ctx))
+	}
 	entry := Entry{
 		Level:     level,
 		Message:   msg,
 		Time:      time.Now(),
 		Component: l.component,
 		Error:     err,
-		Fields:    append(l.fields, fields...),
+		Fields:    mergeFields(base, fields),
+	}
+	if l.maxField > 0 {
+		truncateFields(entry.Fields, l.maxField)
 	}
 
 	// Add trace information if available
@@ -131,27 +317,164 @@ func (l *defaultLogger) log(ctx context.Context, level Level, msg string, err er
 		entry.SpanID = spanID
 	}
 
-	// Convert entry to JSON
-	data, err := json.Marshal(map[string]interface{}{
-		"level":     entry.Level.String(),
-		"time":      entry.Time.Format(time.RFC3339),
-		"msg":       entry.Message,
-		"component": entry.Component,
-		"trace_id":  entry.TraceID,
-		"span_id":   entry.SpanID,
-		"fields":    fieldsToMap(entry.Fields),
-		"error":     errorToString(entry.Error),
-	})
-	if err != nil {
-		// If JSON marshaling fails, write a simple error message
-		fmt.Fprintf(l.out, "failed to marshal log entry: %v\n", err)
+	out := l.output(level)
+	if l.text {
+		out.WriteLevel(level, append(formatText(entry), '\n'))
+		return
+	}
+
+	// Flattened entries and custom marshalers work on the entry map
+	if l.flatten || l.marshal != nil {
+		marshal := l.marshal
+		if marshal == nil {
+			marshal = json.Marshal
+		}
+		data, err := marshal(l.entryToMap(entry))
+		if err != nil {
+			// If JSON marshaling fails, write a simple error message
+			fmt.Fprintf(out, "failed to marshal log entry: %v\n", err)
+			return
+		}
+
+		// Write the log entry
+		out.WriteLevel(level, append(data, '\n'))
+		return
+	}
+
+	// Nested fields are encoded straight into a pooled buffer
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := appendEntryJSON(buf, entry); err != nil {
+		fmt.Fprintf(out, "failed to marshal log entry: %v\n", err)
 		return
 	}
+	buf.WriteByte('\n')
+	out.WriteLevel(level, buf.Bytes())
+}
+
+// formatText formats an entry as a single human-readable line
+func formatText(entry Entry) []byte {
+	var sb strings.Builder
+	sb.WriteString(entry.Time.Format(time.RFC3339))
+	sb.WriteByte(' ')
+	sb.WriteString(entry.Level.String())
+	if entry.Component != "" {
+		sb.WriteString(" [")
+		sb.WriteString(entry.Component)
+		sb.WriteByte(']')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(entry.Message)
+	if entry.TraceID != "" {
+		fmt.Fprintf(&sb, " trace_id=%s", entry.TraceID)
+	}
+	if entry.SpanID != "" {
+		fmt.Fprintf(&sb, " span_id=%s", entry.SpanID)
+	}
+	for _, f := range entry.Fields {
+		fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+	}
+	if entry.Error != nil {
+		fmt.Fprintf(&sb, " error=%q", entry.Error.Error())
+	}
+	return []byte(sb.String())
+}
+
+// entryToMap builds the JSON object for an entry, omitting empty optional
+// keys. When flattening, fields are placed at the top level and the
+// standard keys take precedence over fields with the same name.
+func (l *defaultLogger) entryToMap(entry Entry) map[string]interface{} {
+	var m map[string]interface{}
+	if l.flatten {
+		m = fieldsToMap(entry.Fields)
+	} else {
+		m = map[string]interface{}{
+			"fields": fieldsToMap(entry.Fields),
+		}
+	}
+
+	m["level"] = entry.Level.String()
+	m["time"] = entry.Time.Format(time.RFC3339)
+	m["msg"] = entry.Message
+	if entry.Component != "" {
+		m["component"] = entry.Component
+	}
+	if entry.TraceID != "" {
+		m["trace_id"] = entry.TraceID
+	}
+	if entry.SpanID != "" {
+		m["span_id"] = entry.SpanID
+	}
+	if entry.Error != nil {
+		m["error"] = errorToMap(entry.Error)
+	}
 
-	// Write the log entry
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.out.Write(append(data, '\n'))
+	return m
+}
+
+// contextFields returns a field for each context key with a value in ctx
+func (l *defaultLogger) contextFields(ctx context.Context) []Field {
+	var fields []Field
+	for _, key := range l.ctxKeys {
+		if v := ctx.Value(key); v != nil {
+			fields = append(fields, Field{Key: string(key), Value: v})
+		}
+	}
+	return fields
+}
+
+// mergeFields returns base followed by extra in a new slice, so loggers
+// derived from the same parent never share a backing array. A field whose
+// key is already present replaces the earlier value in its position.
+func mergeFields(base, extra []Field) []Field {
+	merged := make([]Field, 0, len(base)+len(extra))
+	for _, fields := range [][]Field{base, extra} {
+		for _, f := range fields {
+			if i := fieldIndex(merged, f.Key); i >= 0 {
+				merged[i] = f
+				continue
+			}
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
+
+// fieldIndex returns the index of the field with key, or -1. Loggers carry
+// few fields, so a linear scan beats building a map on every entry.
+func fieldIndex(fields []Field, key string) int {
+	for i, f := range fields {
+		if f.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncateFields caps string and byte slice field values at n bytes,
+// marking truncated values with their original length
+func truncateFields(fields []Field, n int) {
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case string:
+			if len(v) > n {
+				fields[i].Value = truncate(v, n)
+			}
+		case []byte:
+			if len(v) > n {
+				fields[i].Value = truncate(string(v), n)
+			}
+		}
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a multi-byte rune
+func truncate(s string, n int) string {
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s…(truncated, %d bytes)", s[:cut], len(s))
 }
 
 // fieldsToMap converts Fields to a map
@@ -163,10 +486,36 @@ func fieldsToMap(fields []Field) map[string]interface{} {
 	return result
 }
 
-// errorToString converts an error to a string
-func errorToString(err error) string {
-	if err == nil {
-		return ""
+// errorToMap converts an error to a JSON object. An *errors.Error
+// contributes its code, message and metadata, and its cause when set. An
+// error wrapping one keeps its own message, so the added context is not
+// lost, along with the code and metadata of the *errors.Error; any other
+// error becomes {message}.
+func errorToMap(err error) map[string]interface{} {
+	var appErr *apperrors.Error
+	if !stderrors.As(err, &appErr) {
+		return map[string]interface{}{"message": err.Error()}
+	}
+
+	metadata := appErr.Metadata
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if _, top := err.(*apperrors.Error); !top {
+		return map[string]interface{}{
+			"code":     appErr.Code,
+			"message":  err.Error(),
+			"metadata": metadata,
+		}
+	}
+
+	m := map[string]interface{}{
+		"code":     appErr.Code,
+		"message":  appErr.Message,
+		"metadata": metadata,
+	}
+	if appErr.Err != nil {
+		m["cause"] = appErr.Err.Error()
 	}
-	return err.Error()
+	return m
 }
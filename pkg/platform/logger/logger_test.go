@@ -0,0 +1,420 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+	apperrors "order-system/pkg/infra/errors"
+)
+
+// newBufferLogger returns a debug-level logger writing every entry to the
+// returned buffer, with cfg adjusted by configure when non-nil
+func newBufferLogger(t *testing.T, configure func(*config.Config), opts ...Option) (*defaultLogger, *bytes.Buffer) {
+	t.Helper()
+	t.Setenv(LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "debug"
+	cfg.Logger.Output = "stdout"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	buf := &bytes.Buffer{}
+	all := []Option{
+		WithLevelOutput(Debug, buf),
+		WithLevelOutput(Info, buf),
+		WithLevelOutput(Warn, buf),
+		WithLevelOutput(Error, buf),
+	}
+	l, err := New(cfg, append(all, opts...)...)
+	require.NoError(t, err)
+	return l.(*defaultLogger), buf
+}
+
+// entries decodes each JSON line in buf
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var decoded []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &m), line)
+		decoded = append(decoded, m)
+	}
+	return decoded
+}
+
+// keys returns the keys of m
+func keys(m map[string]interface{}) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+func TestJSONEntryOmitsEmptyKeys(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+
+	l.Info(context.Background(), "order placed")
+
+	got := entries(t, buf)
+	require.Len(t, got, 1)
+	assert.ElementsMatch(t, []string{"fields", "level", "msg", "time"}, keys(got[0]))
+	assert.Empty(t, got[0]["fields"])
+}
+
+func TestJSONEntryKeysAreSorted(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+	ctx := context.WithValue(context.Background(), "trace_id", "t1")
+
+	l.WithComponent("orders").Info(ctx, "order placed", Field{Key: "b", Value: 2}, Field{Key: "a", Value: 1})
+
+	line := buf.String()
+	order := []string{`"component"`, `"fields":{"a":1,"b":2}`, `"level"`, `"msg"`, `"time"`, `"trace_id"`}
+	last := -1
+	for _, key := range order {
+		i := strings.Index(line, key)
+		require.Greater(t, i, last, "%s out of order in %s", key, line)
+		last = i
+	}
+}
+
+func TestFlattenedEntryLayout(t *testing.T) {
+	l, buf := newBufferLogger(t, func(c *config.Config) { c.Logger.FlattenFields = true })
+
+	l.Info(context.Background(), "order placed",
+		Field{Key: "order_id", Value: "o-1"},
+		Field{Key: "msg", Value: "shadowed"},
+	)
+
+	got := entries(t, buf)
+	require.Len(t, got, 1)
+	assert.ElementsMatch(t, []string{"level", "msg", "order_id", "time"}, keys(got[0]))
+	assert.Equal(t, "o-1", got[0]["order_id"])
+	assert.Equal(t, "order placed", got[0]["msg"], "standard keys win over fields")
+}
+
+// fieldsOf returns the nested fields of each JSON entry in buf
+func fieldsOf(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var fields []map[string]interface{}
+	for _, e := range entries(t, buf) {
+		fields = append(fields, e["fields"].(map[string]interface{}))
+	}
+	return fields
+}
+
+func TestWithComponentFieldsSiblingsDoNotLeak(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+	// Spare capacity is what an appending implementation would alias
+	l.fields = append(make([]Field, 0, 8), Field{Key: "service", Value: "orders"})
+
+	orders := l.WithComponentFields("orders", Field{Key: "subsystem", Value: "orders"})
+	payments := l.WithComponentFields("payments", Field{Key: "subsystem", Value: "payments"}, Field{Key: "psp", Value: "stripe"})
+	orders.Info(context.Background(), "one")
+	payments.Info(context.Background(), "two")
+	l.Info(context.Background(), "three")
+
+	got := entries(t, buf)
+	require.Len(t, got, 3)
+	assert.Equal(t, "orders", got[0]["component"])
+	assert.Equal(t, map[string]interface{}{"service": "orders", "subsystem": "orders"}, got[0]["fields"])
+	assert.Equal(t, "payments", got[1]["component"])
+	assert.Equal(t, map[string]interface{}{"service": "orders", "subsystem": "payments", "psp": "stripe"}, got[1]["fields"])
+	assert.Nil(t, got[2]["component"])
+	assert.Equal(t, map[string]interface{}{"service": "orders"}, got[2]["fields"])
+}
+
+func TestWithFieldsDerivedLoggersDoNotShareFields(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+	l.fields = append(make([]Field, 0, 8), Field{Key: "service", Value: "orders"})
+
+	first := l.WithFields(Field{Key: "request_id", Value: "r-1"})
+	second := l.WithFields(Field{Key: "user_id", Value: "u-2"})
+	first.Info(context.Background(), "first")
+	second.Info(context.Background(), "second")
+
+	assert.Equal(t, []map[string]interface{}{
+		{"service": "orders", "request_id": "r-1"},
+		{"service": "orders", "user_id": "u-2"},
+	}, fieldsOf(t, buf))
+	assert.Len(t, l.fields, 1, "the parent keeps its own fields")
+}
+
+func TestCloseClosesFileAndDropsLaterWrites(t *testing.T) {
+	t.Setenv(LevelEnv, "")
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "info"
+	cfg.Logger.Output = path
+	l, err := New(cfg)
+	require.NoError(t, err)
+	derived := l.WithComponent("orders")
+
+	l.Info(context.Background(), "before close")
+	require.NoError(t, derived.Close(), "closing a derived logger closes the shared file")
+
+	file := l.(*defaultLogger).out.w.(*os.File)
+	_, err = file.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	assert.NotPanics(t, func() {
+		l.Info(context.Background(), "after close")
+		derived.Error(context.Background(), "after close", nil)
+	})
+	assert.NoError(t, l.Close(), "closing twice is a no-op")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+	assert.Contains(t, string(data), "before close")
+}
+
+func TestCloseLeavesStdoutOpen(t *testing.T) {
+	l, _ := newBufferLogger(t, nil)
+
+	require.NoError(t, l.Close())
+
+	_, err := os.Stdout.Stat()
+	assert.NoError(t, err)
+}
+
+func TestErrorObjectShape(t *testing.T) {
+	appErr := apperrors.Wrap(errors.New("no rows"), "ORDER_NOT_FOUND", "order not found").
+		WithMetadata("order_id", "ord-1")
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{
+			name: "application error",
+			err:  appErr,
+			want: map[string]interface{}{
+				"code":     "ORDER_NOT_FOUND",
+				"message":  "order not found",
+				"metadata": map[string]interface{}{"order_id": "ord-1"},
+				"cause":    "no rows",
+			},
+		},
+		{
+			name: "wrapped application error",
+			err:  fmt.Errorf("load order: %w", appErr),
+			want: map[string]interface{}{
+				"code":     "ORDER_NOT_FOUND",
+				"message":  "load order: ORDER_NOT_FOUND: order not found: no rows",
+				"metadata": map[string]interface{}{"order_id": "ord-1"},
+			},
+		},
+		{
+			name: "application error without metadata or cause",
+			err:  apperrors.New("INVALID", "invalid order"),
+			want: map[string]interface{}{
+				"code":     "INVALID",
+				"message":  "invalid order",
+				"metadata": map[string]interface{}{},
+			},
+		},
+		{
+			name: "plain error",
+			err:  errors.New("connection reset"),
+			want: map[string]interface{}{"message": "connection reset"},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, flatten := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/flatten=%v", tt.name, flatten), func(t *testing.T) {
+				l, buf := newBufferLogger(t, func(c *config.Config) { c.Logger.FlattenFields = flatten })
+
+				l.Error(context.Background(), "failed", tt.err)
+
+				got := entries(t, buf)
+				require.Len(t, got, 1)
+				assert.Equal(t, tt.want, got[0]["error"])
+			})
+		}
+	}
+}
+
+// syncRecorder is a buffered, syncable output recording each call
+type syncRecorder struct {
+	calls []string
+}
+
+func (s *syncRecorder) Write(p []byte) (int, error) {
+	s.calls = append(s.calls, "write")
+	return len(p), nil
+}
+
+func (s *syncRecorder) Flush() error {
+	s.calls = append(s.calls, "flush")
+	return nil
+}
+
+func (s *syncRecorder) Sync() error {
+	s.calls = append(s.calls, "sync")
+	return nil
+}
+
+// fileLogger returns a logger writing to a file in a temp dir, with
+// cfg.Logger.Sync set to sync
+func fileLogger(t *testing.T, sync bool) *defaultLogger {
+	t.Helper()
+	t.Setenv(LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "debug"
+	cfg.Logger.Output = filepath.Join(t.TempDir(), "audit.log")
+	cfg.Logger.Sync = sync
+	l, err := New(cfg)
+	require.NoError(t, err)
+	// Close the file itself, as tests swap out the logger's writer
+	file := l.(*defaultLogger).out.w.(*os.File)
+	t.Cleanup(func() { file.Close() })
+	return l.(*defaultLogger)
+}
+
+func TestSyncAfterEachLogCall(t *testing.T) {
+	l := fileLogger(t, true)
+	rec := &syncRecorder{}
+	l.out.w = rec
+
+	l.Info(context.Background(), "order placed")
+	l.Error(context.Background(), "charge failed", nil)
+
+	assert.Equal(t, []string{"write", "flush", "sync", "write", "flush", "sync"}, rec.calls)
+}
+
+func TestSyncOffByDefault(t *testing.T) {
+	l := fileLogger(t, false)
+	rec := &syncRecorder{}
+	l.out.w = rec
+
+	l.Info(context.Background(), "order placed")
+
+	assert.Equal(t, []string{"write"}, rec.calls)
+}
+
+func TestSyncIgnoredForStandardStreams(t *testing.T) {
+	l, _ := newBufferLogger(t, func(c *config.Config) { c.Logger.Sync = true })
+
+	assert.False(t, l.out.sync)
+}
+
+// envLevelLogger returns an info-level logger, created after the caller
+// has set up LevelEnv, writing every entry to the returned buffer
+func envLevelLogger(t *testing.T) (*defaultLogger, *bytes.Buffer) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Logger.Level = "info"
+	cfg.Logger.Output = "stdout"
+
+	buf := &bytes.Buffer{}
+	l, err := New(cfg,
+		WithLevelOutput(Debug, buf),
+		WithLevelOutput(Info, buf),
+		WithLevelOutput(Warn, buf),
+		WithLevelOutput(Error, buf),
+	)
+	require.NoError(t, err)
+	return l.(*defaultLogger), buf
+}
+
+func TestLevelEnvOverridesConfig(t *testing.T) {
+	t.Setenv(LevelEnv, "DEBUG")
+	l, buf := envLevelLogger(t)
+
+	l.Debug(context.Background(), "cache miss")
+
+	assert.Equal(t, Debug, l.level)
+	assert.Equal(t, []string{"cache miss"}, messages(t, buf))
+}
+
+func TestLevelEnvInvalidIgnoredWithWarning(t *testing.T) {
+	t.Setenv(LevelEnv, "loud")
+	l, buf := envLevelLogger(t)
+
+	l.Debug(context.Background(), "cache miss")
+
+	assert.Equal(t, Info, l.level, "the configured level is kept")
+	got := entries(t, buf)
+	require.Len(t, got, 1)
+	assert.Equal(t, "WARN", got[0]["level"])
+	assert.Equal(t, "ignoring "+LevelEnv, got[0]["msg"])
+	assert.Contains(t, got[0]["error"].(map[string]interface{})["message"], "loud")
+}
+
+func TestLevelEnvUnset(t *testing.T) {
+	// Setenv restores the variable after the test, which Unsetenv alone
+	// would not
+	t.Setenv(LevelEnv, "")
+	for _, unset := range []bool{false, true} {
+		if unset {
+			require.NoError(t, os.Unsetenv(LevelEnv))
+		}
+		l, buf := envLevelLogger(t)
+
+		l.Debug(context.Background(), "cache miss")
+
+		assert.Equal(t, Info, l.level)
+		assert.Empty(t, buf.String(), "no warning without an override")
+	}
+}
+
+func TestWithFieldsLaterValueWins(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+
+	l.WithFields(Field{Key: "k", Value: 1}).WithFields(Field{Key: "k", Value: 2}).Info(context.Background(), "chained")
+	l.WithFields(Field{Key: "k", Value: 1}, Field{Key: "k", Value: 3}).Info(context.Background(), "same call")
+	l.WithFields(Field{Key: "k", Value: 1}).Info(context.Background(), "at call site", Field{Key: "k", Value: 4})
+
+	assert.Equal(t, []map[string]interface{}{
+		{"k": 2.0},
+		{"k": 3.0},
+		{"k": 4.0},
+	}, fieldsOf(t, buf))
+}
+
+func TestWithFieldsKeepsFirstPositionOfReplacedKey(t *testing.T) {
+	l, _ := newBufferLogger(t, nil)
+
+	derived := l.WithFields(
+		Field{Key: "a", Value: 1},
+		Field{Key: "b", Value: 2},
+	).WithFields(
+		Field{Key: "c", Value: 3},
+		Field{Key: "a", Value: 4},
+	).(*defaultLogger)
+
+	assert.Equal(t, []Field{
+		{Key: "a", Value: 4},
+		{Key: "b", Value: 2},
+		{Key: "c", Value: 3},
+	}, derived.fields)
+}
+
+func TestTextFormatWritesReplacedKeyOnce(t *testing.T) {
+	l, buf := newBufferLogger(t, func(cfg *config.Config) { cfg.Logger.Format = "text" })
+
+	l.WithFields(Field{Key: "a", Value: 1}, Field{Key: "b", Value: 2}).
+		WithFields(Field{Key: "a", Value: 3}).
+		Info(context.Background(), "order placed")
+
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasSuffix(line, "order placed a=3 b=2"), line)
+}
@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"context"
+)
+
+// nopLogger is a Logger that discards everything
+type nopLogger struct{}
+
+// NewNop returns a Logger that discards every entry
+func NewNop() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(ctx context.Context, msg string, fields ...Field)            {}
+func (nopLogger) Info(ctx context.Context, msg string, fields ...Field)             {}
+func (nopLogger) Warn(ctx context.Context, msg string, fields ...Field)             {}
+func (nopLogger) Error(ctx context.Context, msg string, err error, fields ...Field) {}
+func (n nopLogger) WithComponent(component string) Logger                           { return n }
+func (n nopLogger) WithComponentFields(component string, fields ...Field) Logger    { return n }
+func (n nopLogger) WithFields(fields ...Field) Logger                               { return n }
+func (nopLogger) Close() error                                                      { return nil }
@@ -0,0 +1,27 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _ Logger = nopLogger{}
+
+func TestNopLoggerNeverPanics(t *testing.T) {
+	l := NewNop()
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		l.Debug(ctx, "debug", Field{Key: "k", Value: 1})
+		l.Info(ctx, "info")
+		l.Warn(ctx, "warn")
+		l.Error(ctx, "error", errors.New("boom"))
+	})
+
+	assert.Equal(t, l, l.WithComponent("orders"))
+	assert.Equal(t, l, l.WithComponentFields("orders", Field{Key: "k", Value: 1}))
+	assert.Equal(t, l, l.WithFields(Field{Key: "k", Value: 1}))
+}
@@ -0,0 +1,44 @@
+package logger
+
+import "io"
+
+// Option configures a logger created by New
+type Option func(*defaultLogger)
+
+// WithLevelOutput writes entries of the given level to w instead of the
+// default output. A nil w keeps the default output for that level.
+func WithLevelOutput(level Level, w io.Writer) Option {
+	return func(l *defaultLogger) {
+		outputs := make(map[Level]*lockedWriter, len(l.outputs)+1)
+		for lvl, out := range l.outputs {
+			outputs[lvl] = out
+		}
+		outputs[level] = l.writerFor(w)
+		l.outputs = outputs
+	}
+}
+
+// WithMaxFieldSize truncates string and byte field values longer than n
+// bytes
+func WithMaxFieldSize(n int) Option {
+	return func(l *defaultLogger) {
+		l.maxField = n
+	}
+}
+
+// WithContextFields adds the value stored under each key in the context of
+// a log call as a field, omitting keys with no value
+func WithContextFields(keys ...ContextKey) Option {
+	return func(l *defaultLogger) {
+		merged := make([]ContextKey, 0, len(l.ctxKeys)+len(keys))
+		merged = append(merged, l.ctxKeys...)
+		l.ctxKeys = append(merged, keys...)
+	}
+}
+
+// WithMarshaler encodes JSON entries with fn instead of encoding/json
+func WithMarshaler(fn MarshalFunc) Option {
+	return func(l *defaultLogger) {
+		l.marshal = fn
+	}
+}
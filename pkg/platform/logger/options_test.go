@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLevelOutputRoutesByLevel(t *testing.T) {
+	var debug, errs bytes.Buffer
+	l, rest := newBufferLogger(t, nil, WithLevelOutput(Debug, &debug), WithLevelOutput(Error, &errs))
+	ctx := context.Background()
+
+	l.Debug(ctx, "cache miss")
+	l.Info(ctx, "order placed")
+	l.Warn(ctx, "slow payment")
+	l.Error(ctx, "charge failed", nil)
+
+	assert.Equal(t, []string{"cache miss"}, messages(t, &debug))
+	assert.Equal(t, []string{"order placed", "slow payment"}, messages(t, rest))
+	assert.Equal(t, []string{"charge failed"}, messages(t, &errs))
+}
+
+func TestWithLevelOutputSharesLockForRepeatedWriter(t *testing.T) {
+	var shared bytes.Buffer
+	l, _ := newBufferLogger(t, nil, WithLevelOutput(Warn, &shared), WithLevelOutput(Error, &shared))
+
+	assert.Same(t, l.output(Warn), l.output(Error))
+	assert.Same(t, l.out, l.writerFor(nil), "a nil writer keeps the default output")
+}
+
+// messages returns the msg of each JSON entry in buf
+func messages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var msgs []string
+	for _, e := range entries(t, buf) {
+		msgs = append(msgs, e["msg"].(string))
+	}
+	return msgs
+}
+
+func TestWithMaxFieldSizeTruncatesLongValues(t *testing.T) {
+	l, buf := newBufferLogger(t, nil, WithMaxFieldSize(4))
+	long := l.WithFields(Field{Key: "body", Value: "abcdefgh"})
+
+	long.Info(context.Background(), "response",
+		Field{Key: "raw", Value: []byte("0123456789")},
+		Field{Key: "id", Value: "ord1"},
+		Field{Key: "status", Value: 500},
+	)
+	long.Info(context.Background(), "again")
+
+	fields := fieldsOf(t, buf)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "abcd…(truncated, 8 bytes)", fields[0]["body"])
+	assert.Equal(t, "0123…(truncated, 10 bytes)", fields[0]["raw"])
+	assert.Equal(t, "ord1", fields[0]["id"], "a value at the limit is untouched")
+	assert.Equal(t, 500.0, fields[0]["status"], "non-string values are untouched")
+	assert.Equal(t, "abcd…(truncated, 8 bytes)", fields[1]["body"], "the logger's own fields are not modified")
+}
+
+func TestWithMaxFieldSizeKeepsRunesWhole(t *testing.T) {
+	l, buf := newBufferLogger(t, nil, WithMaxFieldSize(4))
+
+	// "é" is two bytes, so a cut at four bytes would land inside the second
+	l.Info(context.Background(), "note",
+		Field{Key: "text", Value: "aééé"},
+		Field{Key: "raw", Value: []byte("aééé")},
+	)
+
+	fields := fieldsOf(t, buf)[0]
+	assert.Equal(t, "aé…(truncated, 7 bytes)", fields["text"])
+	assert.Equal(t, "aé…(truncated, 7 bytes)", fields["raw"])
+	assert.True(t, utf8.ValidString(fields["text"].(string)))
+}
+
+func TestFieldsUntruncatedByDefault(t *testing.T) {
+	l, buf := newBufferLogger(t, nil)
+	body := strings.Repeat("x", 1<<16)
+
+	l.Info(context.Background(), "response", Field{Key: "body", Value: body})
+
+	assert.Equal(t, body, fieldsOf(t, buf)[0]["body"])
+}
+
+func TestWithContextFieldsAddsPresentValues(t *testing.T) {
+	const userID, requestID ContextKey = "user_id", "request_id"
+	l, buf := newBufferLogger(t, nil, WithContextFields(userID, requestID))
+	ctx := context.WithValue(context.Background(), userID, "u-42")
+
+	l.Info(ctx, "order placed", Field{Key: "order_id", Value: "o-1"})
+	l.WithFields(Field{Key: "service", Value: "orders"}).Info(context.WithValue(ctx, requestID, "r-7"), "order paid")
+
+	fields := fieldsOf(t, buf)
+	require.Len(t, fields, 2)
+	assert.Equal(t, map[string]interface{}{"user_id": "u-42", "order_id": "o-1"}, fields[0], "absent keys are omitted")
+	assert.Equal(t, map[string]interface{}{"user_id": "u-42", "request_id": "r-7", "service": "orders"}, fields[1])
+}
+
+func TestWithContextFieldsExplicitFieldWins(t *testing.T) {
+	const userID ContextKey = "user_id"
+	l, buf := newBufferLogger(t, nil, WithContextFields(userID))
+	ctx := context.WithValue(context.Background(), userID, "u-42")
+
+	l.Info(ctx, "impersonating", Field{Key: "user_id", Value: "u-1"})
+
+	assert.Equal(t, "u-1", fieldsOf(t, buf)[0]["user_id"])
+}
+
+func TestWithContextFieldsIgnoresOtherKeyTypes(t *testing.T) {
+	type otherKey string
+	l, buf := newBufferLogger(t, nil, WithContextFields("user_id"))
+	ctx := context.WithValue(context.Background(), otherKey("user_id"), "u-42")
+
+	l.Info(ctx, "order placed")
+
+	assert.Empty(t, fieldsOf(t, buf)[0])
+}
+
+func TestWithMarshalerOutputIsUsed(t *testing.T) {
+	var got []map[string]interface{}
+	marshal := func(v interface{}) ([]byte, error) {
+		m := v.(map[string]interface{})
+		got = append(got, m)
+		return []byte("custom " + m["level"].(string) + " " + m["msg"].(string)), nil
+	}
+	l, buf := newBufferLogger(t, nil, WithMarshaler(marshal))
+
+	l.Info(context.Background(), "order placed", Field{Key: "order_id", Value: "o-1"})
+
+	assert.Equal(t, "custom INFO order placed\n", buf.String())
+	require.Len(t, got, 1)
+	assert.Equal(t, map[string]interface{}{"order_id": "o-1"}, got[0]["fields"])
+}
+
+func TestWithMarshalerErrorIsReported(t *testing.T) {
+	marshal := func(interface{}) ([]byte, error) { return nil, errors.New("unsupported") }
+	l, buf := newBufferLogger(t, nil, WithMarshaler(marshal))
+
+	l.Warn(context.Background(), "slow payment")
+
+	assert.Equal(t, "failed to marshal log entry: unsupported\n", buf.String())
+}
+
+func TestWithMarshalerControlsHTMLEscaping(t *testing.T) {
+	marshal := func(v interface{}) ([]byte, error) {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+	}
+	escaped, escapedBuf := newBufferLogger(t, nil)
+	raw, rawBuf := newBufferLogger(t, nil, WithMarshaler(marshal))
+
+	escaped.Info(context.Background(), "<b>")
+	raw.Info(context.Background(), "<b>")
+
+	assert.Contains(t, escapedBuf.String(), `"msg":"\u003cb\u003e"`)
+	assert.Contains(t, rawBuf.String(), `"msg":"<b>"`)
+	assert.Equal(t, []string{"<b>"}, messages(t, rawBuf))
+}
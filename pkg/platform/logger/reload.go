@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"context"
+
+	"order-system/pkg/infra/config"
+)
+
+// LogConfigReloads logs every reload attempt of p on l: an info entry on
+// success, an error entry when the previous configuration was kept
+func LogConfigReloads(p *config.Provider, l Logger) {
+	l = l.WithComponent("config")
+	p.OnReload(func(err error) {
+		if err != nil {
+			l.Error(context.Background(), "config reload failed, keeping previous config", err)
+			return
+		}
+		l.Info(context.Background(), "config reloaded")
+	})
+}
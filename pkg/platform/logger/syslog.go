@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// isSyslogOutput reports whether a logger output refers to syslog
+func isSyslogOutput(output string) bool {
+	return output == "syslog" || strings.HasPrefix(output, "syslog://") ||
+		strings.HasPrefix(output, "syslog+tcp://") || strings.HasPrefix(output, "syslog+udp://")
+}
+
+// parseSyslogTarget parses a syslog output into a network and address.
+// "syslog" is the local daemon, "syslog://host:port" and
+// "syslog+udp://host:port" use UDP, and "syslog+tcp://host:port" uses TCP.
+func parseSyslogTarget(output string) (network, addr string, err error) {
+	if output == "syslog" {
+		return "", "", nil
+	}
+
+	u, err := url.Parse(output)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog output %q: %w", output, err)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("invalid syslog output %q: missing host", output)
+	}
+
+	switch u.Scheme {
+	case "syslog", "syslog+udp":
+		return "udp", u.Host, nil
+	case "syslog+tcp":
+		return "tcp", u.Host, nil
+	default:
+		return "", "", fmt.Errorf("invalid syslog output %q: unknown scheme %s", output, u.Scheme)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog reports that syslog is unavailable on this platform
+func openSyslog(output string) (io.Writer, error) {
+	return nil, fmt.Errorf("syslog output is not supported on this platform")
+}
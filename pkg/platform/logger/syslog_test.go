@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyslogTarget(t *testing.T) {
+	tests := []struct {
+		output  string
+		network string
+		addr    string
+		wantErr bool
+	}{
+		{output: "syslog"},
+		{output: "syslog://logs:514", network: "udp", addr: "logs:514"},
+		{output: "syslog+udp://logs:514", network: "udp", addr: "logs:514"},
+		{output: "syslog+tcp://logs:601", network: "tcp", addr: "logs:601"},
+		{output: "syslog+tcp://", wantErr: true},
+		{output: "syslog+tls://logs:6514", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			network, addr, err := parseSyslogTarget(tt.output)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.network, network)
+			assert.Equal(t, tt.addr, addr)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"strings"
+)
+
+// syslogWriter writes entries to syslog at the severity of their level
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+// openSyslog connects to the syslog daemon described by output
+func openSyslog(output string) (io.Writer, error) {
+	network, addr, err := parseSyslogTarget(output)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+// Write implements io.Writer, logging at info severity
+func (s *syslogWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(Info, p)
+}
+
+// WriteLevel implements levelWriter
+func (s *syslogWriter) WriteLevel(level Level, p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+
+	var err error
+	switch syslogSeverity(level) {
+	case syslog.LOG_DEBUG:
+		err = s.w.Debug(msg)
+	case syslog.LOG_WARNING:
+		err = s.w.Warning(msg)
+	case syslog.LOG_ERR:
+		err = s.w.Err(msg)
+	default:
+		err = s.w.Info(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the syslog connection
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+// syslogSeverity maps a level to its syslog severity
+func syslogSeverity(level Level) syslog.Priority {
+	switch level {
+	case Debug:
+		return syslog.LOG_DEBUG
+	case Warn:
+		return syslog.LOG_WARNING
+	case Error:
+		return syslog.LOG_ERR
+	default:
+		return syslog.LOG_INFO
+	}
+}
@@ -0,0 +1,88 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/syslog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+// listenSyslog starts a UDP listener standing in for a remote syslog daemon
+func listenSyslog(t *testing.T) net.PacketConn {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readSyslog returns the next datagram received by conn
+func readSyslog(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	assert.Equal(t, syslog.LOG_DEBUG, syslogSeverity(Debug))
+	assert.Equal(t, syslog.LOG_INFO, syslogSeverity(Info))
+	assert.Equal(t, syslog.LOG_WARNING, syslogSeverity(Warn))
+	assert.Equal(t, syslog.LOG_ERR, syslogSeverity(Error))
+}
+
+func TestSyslogOutputMapsLevelsToSeverities(t *testing.T) {
+	conn := listenSyslog(t)
+	t.Setenv(LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "debug"
+	cfg.Logger.Output = "syslog://" + conn.LocalAddr().String()
+
+	l, err := New(cfg)
+	require.NoError(t, err)
+	defer l.Close()
+
+	ctx := context.Background()
+	logs := []struct {
+		log      func()
+		severity syslog.Priority
+	}{
+		{func() { l.Debug(ctx, "debug entry") }, syslog.LOG_DEBUG},
+		{func() { l.Info(ctx, "info entry") }, syslog.LOG_INFO},
+		{func() { l.Warn(ctx, "warn entry") }, syslog.LOG_WARNING},
+		{func() { l.Error(ctx, "error entry", nil) }, syslog.LOG_ERR},
+	}
+	for _, tt := range logs {
+		tt.log()
+		// The priority prefix is facility*8 + severity
+		priority := fmt.Sprintf("<%d>", syslog.LOG_USER|tt.severity)
+		assert.Regexp(t, "^"+priority, readSyslog(t, conn))
+	}
+}
+
+func TestSyslogOutputUnavailable(t *testing.T) {
+	// Reserve a port, then free it so nothing is listening there
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	t.Setenv(LevelEnv, "")
+	cfg := &config.Config{}
+	cfg.Logger.Level = "info"
+	cfg.Logger.Output = "syslog+tcp://" + addr
+
+	_, err = New(cfg)
+	assert.ErrorContains(t, err, "failed to connect to syslog")
+}
@@ -41,6 +41,10 @@ type Field struct {
 	Value interface{}
 }
 
+// ContextKey is a context key whose value a logger can attach as a field
+// named after the key; see WithContextFields
+type ContextKey string
+
 // Entry represents a log entry
 type Entry struct {
 	Level     Level
@@ -53,6 +57,10 @@ type Entry struct {
 	Error     error
 }
 
+// MarshalFunc encodes a log entry, given as a JSON object map, into a
+// single line
+type MarshalFunc func(v interface{}) ([]byte, error)
+
 // Logger defines the logging interface
 type Logger interface {
 	// Debug logs a debug message
@@ -65,6 +73,13 @@ type Logger interface {
 	Error(ctx context.Context, msg string, err error, fields ...Field)
 	// WithComponent returns a new logger with the component field set
 	WithComponent(component string) Logger
-	// WithFields returns a new logger with the given fields added
+	// WithComponentFields returns a new logger with the component set and
+	// the given fields added
+	WithComponentFields(component string, fields ...Field) Logger
+	// WithFields returns a new logger with the given fields added, each
+	// replacing an existing field with the same key
 	WithFields(fields ...Field) Logger
+	// Close flushes and closes the output if the logger opened it; later
+	// writes are dropped
+	Close() error
 }
@@ -1,8 +1,13 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"order-system/pkg/infra/config"
@@ -11,11 +16,63 @@ import (
 // defaultCollector implements the Collector interface
 type defaultCollector struct {
 	mu           sync.RWMutex
-	counters     map[string]map[string]float64   // name -> labels -> value
-	gauges       map[string]map[string]float64   // name -> labels -> value
-	histograms   map[string]map[string][]float64 // name -> labels -> values
-	descriptions map[string]string               // name -> description
-	types        map[string]MetricType           // name -> type
+	counters     map[string]map[string]*series  // name -> labels -> series
+	gauges       map[string]map[string]*series  // name -> labels -> series
+	gaugeFuncs   map[string]func() float64      // name -> sampler
+	histograms   map[string]map[string]*series  // name -> labels -> series
+	descriptions map[string]string              // name -> description
+	types        map[string]MetricType          // name -> type
+	created      map[string]time.Time           // name -> registration time
+	exemplars    map[string]map[string]Exemplar // name -> labels -> latest exemplar
+	samplers     sync.Map                       // name -> *sampler, read without the lock
+	buckets      map[string][]float64           // name -> histogram buckets
+	ttls         map[string]time.Duration       // name -> series TTL
+	ttlCount     int32                          // len(ttls), read without the lock
+	negative     NegativeCounterMode
+	interval     time.Duration
+	username     string
+	password     string
+	resetCollect bool
+
+	sinkMu     sync.Mutex
+	sinks      map[int]func([]Metric)
+	nextSinkID int
+}
+
+// series is one labelled series of a metric. Counter and gauge values are
+// updated atomically so handles can skip the collector lock; histogram
+// observations are guarded by the lock.
+type series struct {
+	bits    uint64    // math.Float64bits of a counter or gauge value
+	values  []float64 // histogram observations
+	updated int64     // unix nanoseconds of the last update, for TTLs
+	pruned  int32     // set once prune has removed the series
+}
+
+// load returns the counter or gauge value
+func (s *series) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.bits))
+}
+
+// store replaces the counter or gauge value
+func (s *series) store(value float64) {
+	atomic.StoreUint64(&s.bits, math.Float64bits(value))
+}
+
+// swap replaces the counter or gauge value, returning the previous one
+func (s *series) swap(value float64) float64 {
+	return math.Float64frombits(atomic.SwapUint64(&s.bits, math.Float64bits(value)))
+}
+
+// add adds delta to the counter or gauge value
+func (s *series) add(delta float64) {
+	for {
+		old := atomic.LoadUint64(&s.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + delta)
+		if atomic.CompareAndSwapUint64(&s.bits, old, updated) {
+			return
+		}
+	}
 }
 
 // New creates a new metrics collector
@@ -24,17 +81,57 @@ func New(cfg *config.Config) (Collector, error) {
 		return nil, fmt.Errorf("metrics are disabled")
 	}
 
-	return &defaultCollector{
-		counters:     make(map[string]map[string]float64),
-		gauges:       make(map[string]map[string]float64),
-		histograms:   make(map[string]map[string][]float64),
+	negative, err := parseNegativeCounterMode(cfg.Metrics.NegativeCounters)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &defaultCollector{
+		counters:     make(map[string]map[string]*series),
+		gauges:       make(map[string]map[string]*series),
+		gaugeFuncs:   make(map[string]func() float64),
+		histograms:   make(map[string]map[string]*series),
 		descriptions: make(map[string]string),
 		types:        make(map[string]MetricType),
-	}, nil
+		created:      make(map[string]time.Time),
+		exemplars:    make(map[string]map[string]Exemplar),
+		buckets:      make(map[string][]float64),
+		ttls:         make(map[string]time.Duration),
+		negative:     negative,
+		interval:     cfg.Metrics.Interval,
+		username:     cfg.Metrics.Username,
+		password:     cfg.Metrics.Password,
+		resetCollect: cfg.Metrics.ResetOnCollect,
+		sinks:        make(map[int]func([]Metric)),
+	}
+	if err := c.Register(InvalidCounterMetric, Counter, "Negative counter increments that were rejected or clamped"); err != nil {
+		return nil, err
+	}
+	if err := c.Register(InvalidLabelsMetric, Counter, "Metric updates dropped for invalid label names"); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// parseNegativeCounterMode parses the negative counter mode string
+func parseNegativeCounterMode(mode string) (NegativeCounterMode, error) {
+	switch mode {
+	case "", "reject":
+		return RejectNegative, nil
+	case "clamp":
+		return ClampNegative, nil
+	default:
+		return RejectNegative, fmt.Errorf("invalid negative counter mode: %s", mode)
+	}
 }
 
 // Register implements Collector.Register
-func (c *defaultCollector) Register(name string, metricType MetricType, description string) error {
+func (c *defaultCollector) Register(name string, metricType MetricType, description string, opts ...RegisterOption) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -42,23 +139,157 @@ func (c *defaultCollector) Register(name string, metricType MetricType, descript
 		return fmt.Errorf("metric %s already registered", name)
 	}
 
-	c.types[name] = metricType
-	c.descriptions[name] = description
+	def := MetricDef{Name: name, Type: metricType, Description: description}
+	for _, opt := range opts {
+		opt(&def)
+	}
+	c.register(def)
+	return nil
+}
 
-	switch metricType {
+// RegisterAll implements Collector.RegisterAll. Registration is all or
+// nothing: if any definition fails, none are registered and the first
+// failure is returned.
+func (c *defaultCollector) RegisterAll(defs []MetricDef) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if err := validateName(def.Name); err != nil {
+			return err
+		}
+		if _, exists := c.types[def.Name]; exists || seen[def.Name] {
+			return fmt.Errorf("metric %s already registered", def.Name)
+		}
+		seen[def.Name] = true
+	}
+
+	for _, def := range defs {
+		c.register(def)
+	}
+	return nil
+}
+
+// register adds a metric definition. Callers must hold the write lock and
+// have checked the name is free.
+func (c *defaultCollector) register(def MetricDef) {
+	c.types[def.Name] = def.Type
+	c.descriptions[def.Name] = def.Description
+	c.created[def.Name] = time.Now()
+
+	switch def.Type {
 	case Counter:
-		c.counters[name] = make(map[string]float64)
+		c.counters[def.Name] = make(map[string]*series)
 	case Gauge:
-		c.gauges[name] = make(map[string]float64)
+		c.gauges[def.Name] = make(map[string]*series)
 	case Histogram:
-		c.histograms[name] = make(map[string][]float64)
+		c.histograms[def.Name] = make(map[string]*series)
+		if def.SampleRate > 1 {
+			c.samplers.Store(def.Name, &sampler{rate: uint64(def.SampleRate)})
+		}
+		if len(def.Buckets) > 0 {
+			buckets := append([]float64{}, def.Buckets...)
+			sort.Float64s(buckets)
+			c.buckets[def.Name] = buckets
+		}
+	}
+}
+
+// SetTTL implements Collector.SetTTL. Series of the metric that are not
+// updated within ttl are pruned on the next Collect or Export; a zero ttl
+// disables pruning.
+func (c *defaultCollector) SetTTL(name string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.types[name]; !exists {
+		return fmt.Errorf("metric %s not registered", name)
+	}
+
+	if ttl <= 0 {
+		delete(c.ttls, name)
+		atomic.StoreInt32(&c.ttlCount, int32(len(c.ttls)))
+		return nil
+	}
+
+	c.ttls[name] = ttl
+	atomic.StoreInt32(&c.ttlCount, int32(len(c.ttls)))
+
+	// Existing series start their TTL now
+	now := time.Now().UnixNano()
+	for _, byKey := range c.seriesOf(name) {
+		for _, s := range byKey {
+			atomic.StoreInt64(&s.updated, now)
+		}
 	}
 
 	return nil
 }
 
+// seriesOf returns the series maps a metric's series may live in
+func (c *defaultCollector) seriesOf(name string) []map[string]*series {
+	return []map[string]*series{c.counters[name], c.gauges[name], c.histograms[name]}
+}
+
+// seriesFor returns the series of name identified by its label key in
+// byName, creating it if needed. Callers must hold the write lock.
+func (c *defaultCollector) seriesFor(byName map[string]map[string]*series, name, key string) *series {
+	byKey, exists := byName[name]
+	if !exists {
+		byKey = make(map[string]*series)
+		byName[name] = byKey
+	}
+	s, exists := byKey[key]
+	if !exists {
+		s = &series{updated: time.Now().UnixNano()}
+		byKey[key] = s
+	}
+	return s
+}
+
+// touch records a series update while any metric has a TTL
+func (c *defaultCollector) touch(s *series) {
+	if atomic.LoadInt32(&c.ttlCount) > 0 {
+		atomic.StoreInt64(&s.updated, time.Now().UnixNano())
+	}
+}
+
+// prune removes series that have outlived their metric's TTL. Without any
+// TTL set it returns without taking the lock.
+func (c *defaultCollector) prune(now time.Time) {
+	if atomic.LoadInt32(&c.ttlCount) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, ttl := range c.ttls {
+		for _, byKey := range c.seriesOf(name) {
+			for key, s := range byKey {
+				if now.Sub(time.Unix(0, atomic.LoadInt64(&s.updated))) < ttl {
+					continue
+				}
+				// Handles still holding the series resolve a new one
+				atomic.StoreInt32(&s.pruned, 1)
+				delete(byKey, key)
+				delete(c.exemplars[name], key)
+			}
+		}
+	}
+}
+
 // IncrementCounter implements Collector.IncrementCounter
 func (c *defaultCollector) IncrementCounter(name string, value float64, labels Labels) {
+	if !c.checkLabels(name, labels) {
+		return
+	}
+	c.addCounter(name, labelsToString(labels), value)
+}
+
+// addCounter adds to the counter series identified by its label key
+func (c *defaultCollector) addCounter(name, key string, value float64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -66,11 +297,24 @@ func (c *defaultCollector) IncrementCounter(name string, value float64, labels L
 		return
 	}
 
-	key := labelsToString(labels)
-	if _, exists := c.counters[name]; !exists {
-		c.counters[name] = make(map[string]float64)
+	// Counters only increase; record the bad delta instead of applying it
+	if value < 0 {
+		c.countInvalid(InvalidCounterMetric, name)
+		if c.negative == RejectNegative {
+			return
+		}
+		value = 0
 	}
-	c.counters[name][key] += value
+
+	s := c.seriesFor(c.counters, name, key)
+	s.add(value)
+	c.touch(s)
+}
+
+// countInvalid counts a dropped or altered update of name in the given
+// invalid-update counter. Callers must hold the write lock.
+func (c *defaultCollector) countInvalid(counter, name string) {
+	c.seriesFor(c.counters, counter, labelsToString(Labels{"metric": name})).add(1)
 }
 
 // GetCounter implements Collector.GetCounter
@@ -82,12 +326,18 @@ func (c *defaultCollector) GetCounter(name string, labels Labels) float64 {
 		return 0
 	}
 
-	key := labelsToString(labels)
-	return c.counters[name][key]
+	if s, ok := c.counters[name][labelsToString(labels)]; ok {
+		return s.load()
+	}
+	return 0
 }
 
 // SetGauge implements Collector.SetGauge
 func (c *defaultCollector) SetGauge(name string, value float64, labels Labels) {
+	if !c.checkLabels(name, labels) {
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -95,28 +345,127 @@ func (c *defaultCollector) SetGauge(name string, value float64, labels Labels) {
 		return
 	}
 
-	key := labelsToString(labels)
-	if _, exists := c.gauges[name]; !exists {
-		c.gauges[name] = make(map[string]float64)
-	}
-	c.gauges[name][key] = value
+	s := c.seriesFor(c.gauges, name, labelsToString(labels))
+	s.store(value)
+	c.touch(s)
 }
 
 // GetGauge implements Collector.GetGauge
 func (c *defaultCollector) GetGauge(name string, labels Labels) float64 {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
 	if c.types[name] != Gauge {
+		c.mu.RUnlock()
 		return 0
 	}
 
-	key := labelsToString(labels)
-	return c.gauges[name][key]
+	if fn, ok := c.gaugeFuncs[name]; ok {
+		c.mu.RUnlock()
+		return fn()
+	}
+
+	var value float64
+	if s, ok := c.gauges[name][labelsToString(labels)]; ok {
+		value = s.load()
+	}
+	c.mu.RUnlock()
+	return value
+}
+
+// sampler keeps one in every rate observations of each series of a
+// histogram. Counting per series keeps interleaved label sets from taking
+// every recorded sample between them.
+type sampler struct {
+	rate   uint64
+	counts sync.Map // label key -> *uint64
+}
+
+// counter returns the observation count of the series with the label key
+func (s *sampler) counter(key string) *uint64 {
+	if v, ok := s.counts.Load(key); ok {
+		return v.(*uint64)
+	}
+	v, _ := s.counts.LoadOrStore(key, new(uint64))
+	return v.(*uint64)
+}
+
+// keep reports whether the next observation counted by n should be recorded
+func (s *sampler) keep(n *uint64) bool {
+	return atomic.AddUint64(n, 1)%s.rate == 1
+}
+
+// sample reports whether an observation of the histogram series identified
+// by its label key should be recorded. It runs before taking the lock, so
+// skipped observations cost two map loads and an atomic add.
+func (c *defaultCollector) sample(name, key string) bool {
+	v, ok := c.samplers.Load(name)
+	if !ok {
+		return true
+	}
+	s := v.(*sampler)
+	return s.keep(s.counter(key))
+}
+
+// sampleRate returns the sampling rate of a histogram, 1 when unsampled
+func (c *defaultCollector) sampleRate(name string) float64 {
+	if v, ok := c.samplers.Load(name); ok {
+		return float64(v.(*sampler).rate)
+	}
+	return 1
+}
+
+// RegisterGaugeFunc implements Collector.RegisterGaugeFunc
+func (c *defaultCollector) RegisterGaugeFunc(name string, description string, fn func() float64) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.types[name]; exists {
+		return fmt.Errorf("metric %s already registered", name)
+	}
+
+	c.types[name] = Gauge
+	c.descriptions[name] = description
+	c.created[name] = time.Now()
+	c.gaugeFuncs[name] = fn
+
+	return nil
+}
+
+// sampleGaugeFuncs invokes every registered gauge func. The funcs are called
+// without holding the lock so they are free to use the collector themselves.
+func (c *defaultCollector) sampleGaugeFuncs() map[string]float64 {
+	c.mu.RLock()
+	funcs := make(map[string]func() float64, len(c.gaugeFuncs))
+	for name, fn := range c.gaugeFuncs {
+		funcs[name] = fn
+	}
+	c.mu.RUnlock()
+
+	values := make(map[string]float64, len(funcs))
+	for name, fn := range funcs {
+		values[name] = fn()
+	}
+	return values
 }
 
 // ObserveHistogram implements Collector.ObserveHistogram
 func (c *defaultCollector) ObserveHistogram(name string, value float64, labels Labels) {
+	if !c.checkLabels(name, labels) {
+		return
+	}
+	c.observe(name, labelsToString(labels), value)
+}
+
+// observe records an observation in the histogram series identified by its
+// label key
+func (c *defaultCollector) observe(name, key string, value float64) {
+	if !c.sample(name, key) {
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -124,11 +473,77 @@ func (c *defaultCollector) ObserveHistogram(name string, value float64, labels L
 		return
 	}
 
+	s := c.seriesFor(c.histograms, name, key)
+	s.values = append(s.values, value)
+	c.touch(s)
+}
+
+// ObserveHistogramContext implements Collector.ObserveHistogramContext,
+// capturing an exemplar when ctx carries a trace ID
+func (c *defaultCollector) ObserveHistogramContext(ctx context.Context, name string, value float64, labels Labels) {
+	if !c.checkLabels(name, labels) {
+		return
+	}
 	key := labelsToString(labels)
-	if _, exists := c.histograms[name]; !exists {
-		c.histograms[name] = make(map[string][]float64)
+	if !c.sample(name, key) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.types[name] != Histogram {
+		return
+	}
+
+	s := c.seriesFor(c.histograms, name, key)
+	s.values = append(s.values, value)
+	c.touch(s)
+
+	if traceID, ok := ctx.Value("trace_id").(string); ok && traceID != "" {
+		if _, exists := c.exemplars[name]; !exists {
+			c.exemplars[name] = make(map[string]Exemplar)
+		}
+		c.exemplars[name][key] = Exemplar{
+			TraceID:   traceID,
+			Value:     value,
+			Timestamp: time.Now(),
+		}
 	}
-	c.histograms[name][key] = append(c.histograms[name][key], value)
+}
+
+// ObserveMany implements Collector.ObserveMany, recording a batch of
+// observations under a single lock acquisition
+func (c *defaultCollector) ObserveMany(name string, values []float64, labels Labels) {
+	if !c.checkLabels(name, labels) {
+		return
+	}
+	key := labelsToString(labels)
+	if v, sampled := c.samplers.Load(name); sampled {
+		smp := v.(*sampler)
+		n := smp.counter(key)
+		kept := make([]float64, 0, len(values))
+		for _, v := range values {
+			if smp.keep(n) {
+				kept = append(kept, v)
+			}
+		}
+		values = kept
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.types[name] != Histogram {
+		return
+	}
+
+	s := c.seriesFor(c.histograms, name, key)
+	s.values = append(s.values, values...)
+	c.touch(s)
 }
 
 // GetHistogram implements Collector.GetHistogram
@@ -140,21 +555,111 @@ func (c *defaultCollector) GetHistogram(name string, labels Labels) []float64 {
 		return nil
 	}
 
-	key := labelsToString(labels)
-	return c.histograms[name][key]
+	if s, ok := c.histograms[name][labelsToString(labels)]; ok {
+		return s.values
+	}
+	return nil
 }
 
-// Collect implements Collector.Collect
-func (c *defaultCollector) Collect() []Metric {
+// GetSeries implements Collector.GetSeries
+func (c *defaultCollector) GetSeries(name string) []Metric {
+	c.prune(time.Now())
+	now := time.Now()
+
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	fn, isFunc := c.gaugeFuncs[name]
+	description := c.descriptions[name]
+	var values map[string]*series
+	var metricType MetricType
+	if byKey, ok := c.counters[name]; ok {
+		values, metricType = byKey, Counter
+	} else if byKey, ok := c.gauges[name]; ok {
+		values, metricType = byKey, Gauge
+	}
 
 	var metrics []Metric
+	for labelKey, s := range values {
+		metrics = append(metrics, Metric{
+			Name:        name,
+			Type:        metricType,
+			Value:       s.load(),
+			Labels:      stringToLabels(labelKey),
+			Description: description,
+			Timestamp:   now,
+		})
+	}
+	c.mu.RUnlock()
+
+	// Gauge funcs are sampled outside the lock, as in Collect
+	if isFunc {
+		metrics = append(metrics, Metric{
+			Name:        name,
+			Type:        Gauge,
+			Value:       fn(),
+			Labels:      Labels{},
+			Description: description,
+			Timestamp:   now,
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return labelsToString(metrics[i].Labels) < labelsToString(metrics[j].Labels)
+	})
+	return metrics
+}
+
+// resetSeries clears histogram observations and exemplars. Counters are
+// zeroed as they are read, since handles update them without the lock.
+// Callers must hold the write lock.
+func (c *defaultCollector) resetSeries() {
+	for _, byKey := range c.histograms {
+		for _, s := range byKey {
+			s.values = nil
+		}
+	}
+	for name := range c.exemplars {
+		delete(c.exemplars, name)
+	}
+}
+
+// Collect implements Collector.Collect
+func (c *defaultCollector) Collect() []Metric {
+	return c.CollectInto(nil)
+}
+
+// CollectInto implements Collector.CollectInto
+func (c *defaultCollector) CollectInto(buf []Metric) []Metric {
+	return c.collect(buf, c.resetCollect)
+}
+
+// collect appends every series to buf[:0], resetting them afterwards when
+// reset is set
+func (c *defaultCollector) collect(buf []Metric, reset bool) []Metric {
+	c.prune(time.Now())
+	sampled := c.sampleGaugeFuncs()
+
+	// The write lock keeps observations from landing between reading and
+	// resetting the series
+	if reset {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		defer c.resetSeries()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
+
+	metrics := buf[:0]
 	now := time.Now()
 
 	// Collect counters
 	for name, values := range c.counters {
-		for labelKey, value := range values {
+		for labelKey, s := range values {
+			value := s.load()
+			if reset {
+				// Swapping keeps increments that race the read for the next collect
+				value = s.swap(0)
+			}
 			metrics = append(metrics, Metric{
 				Name:        name,
 				Type:        Counter,
@@ -168,11 +673,11 @@ func (c *defaultCollector) Collect() []Metric {
 
 	// Collect gauges
 	for name, values := range c.gauges {
-		for labelKey, value := range values {
+		for labelKey, s := range values {
 			metrics = append(metrics, Metric{
 				Name:        name,
 				Type:        Gauge,
-				Value:       value,
+				Value:       s.load(),
 				Labels:      stringToLabels(labelKey),
 				Description: c.descriptions[name],
 				Timestamp:   now,
@@ -180,10 +685,26 @@ func (c *defaultCollector) Collect() []Metric {
 		}
 	}
 
+	// Collect gauge funcs
+	for name, value := range sampled {
+		metrics = append(metrics, Metric{
+			Name:        name,
+			Type:        Gauge,
+			Value:       value,
+			Labels:      Labels{},
+			Description: c.descriptions[name],
+			Timestamp:   now,
+		})
+	}
+
 	// Collect histograms
 	for name, values := range c.histograms {
-		for labelKey, histogram := range values {
-			for _, value := range histogram {
+		rate := 0
+		if scale := c.sampleRate(name); scale > 1 {
+			rate = int(scale)
+		}
+		for labelKey, s := range values {
+			for _, value := range s.values {
 				metrics = append(metrics, Metric{
 					Name:        name,
 					Type:        Histogram,
@@ -191,6 +712,7 @@ func (c *defaultCollector) Collect() []Metric {
 					Labels:      stringToLabels(labelKey),
 					Description: c.descriptions[name],
 					Timestamp:   now,
+					SampleRate:  rate,
 				})
 			}
 		}
@@ -199,26 +721,39 @@ func (c *defaultCollector) Collect() []Metric {
 	return metrics
 }
 
-// labelsToString converts Labels to a string key
+// labelsToString converts Labels to a string key. Keys are sorted so the
+// same label set always maps to the same series.
 func labelsToString(labels Labels) string {
 	if len(labels) == 0 {
 		return ""
 	}
 
-	var result string
-	for k, v := range labels {
-		result += fmt.Sprintf("%s=%s;", k, v)
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
 	}
-	return result
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+		sb.WriteByte(';')
+	}
+	return sb.String()
 }
 
 // stringToLabels converts a string key back to Labels
 func stringToLabels(s string) Labels {
-	if s == "" {
-		return Labels{}
-	}
-
 	labels := make(Labels)
-	// Simple implementation - in real code, you'd want to properly parse the string
+	for _, pair := range strings.Split(s, ";") {
+		if pair == "" {
+			continue
+		}
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			labels[pair[:i]] = pair[i+1:]
+		}
+	}
 	return labels
 }
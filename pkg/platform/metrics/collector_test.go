@@ -0,0 +1,556 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+func TestRegisterAllRegistersBatch(t *testing.T) {
+	c := newTestCollector(t)
+
+	require.NoError(t, c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter, Description: "Orders"},
+		{Name: "queue_depth", Type: Gauge, Description: "Depth"},
+		{Name: "latency_seconds", Type: Histogram, Description: "Latency", Buckets: []float64{1, 0.1}},
+	}))
+
+	assert.Equal(t, Counter, c.types["orders_total"])
+	assert.Equal(t, Gauge, c.types["queue_depth"])
+	assert.Equal(t, "Latency", c.descriptions["latency_seconds"])
+	assert.Equal(t, []float64{0.1, 1}, c.buckets["latency_seconds"], "buckets are sorted")
+}
+
+func TestRegisterAllRejectsDuplicateWithinBatch(t *testing.T) {
+	c := newTestCollector(t)
+
+	err := c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter},
+		{Name: "queue_depth", Type: Gauge},
+		{Name: "orders_total", Type: Counter},
+	})
+
+	assert.EqualError(t, err, "metric orders_total already registered")
+	assert.NotContains(t, c.types, "orders_total")
+	assert.NotContains(t, c.types, "queue_depth")
+}
+
+func TestRegisterAllReturnsFirstFailure(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+
+	err := c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter},
+		{Name: "bad name", Type: Gauge},
+		{Name: "queue_depth", Type: Gauge},
+	})
+	assert.EqualError(t, err, "metric orders_total already registered")
+
+	// all or nothing: the valid definition was not registered either
+	assert.NoError(t, c.Register("queue_depth", Gauge, "Depth"))
+}
+
+// newCollectorWithMode returns a collector handling negative counter
+// increments as mode
+func newCollectorWithMode(t testing.TB, mode string) *defaultCollector {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.NegativeCounters = mode
+	c, err := New(cfg)
+	require.NoError(t, err)
+	return c.(*defaultCollector)
+}
+
+func TestNegativeIncrementRejected(t *testing.T) {
+	c := newCollectorWithMode(t, "reject")
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+
+	c.IncrementCounter("orders_total", 5, nil)
+	c.IncrementCounter("orders_total", -2, nil)
+
+	assert.Equal(t, 5.0, c.GetCounter("orders_total", nil))
+	assert.Equal(t, 1.0, c.GetCounter(InvalidCounterMetric, Labels{"metric": "orders_total"}))
+}
+
+func TestNegativeIncrementClamped(t *testing.T) {
+	c := newCollectorWithMode(t, "clamp")
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+
+	c.IncrementCounter("orders_total", -2, Labels{"region": "eu"})
+
+	assert.Equal(t, 0.0, c.GetCounter("orders_total", Labels{"region": "eu"}))
+	assert.Len(t, c.GetSeries("orders_total"), 1, "a clamped increment still creates the series")
+	assert.Equal(t, 1.0, c.GetCounter(InvalidCounterMetric, Labels{"metric": "orders_total"}))
+}
+
+func TestInvalidNegativeCounterMode(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.NegativeCounters = "ignore"
+	_, err := New(cfg)
+	assert.Error(t, err)
+}
+
+// findMetric returns the first metric named name in metrics
+func findMetric(t *testing.T, metrics []Metric, name string) Metric {
+	t.Helper()
+	for _, m := range metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+	t.Fatalf("metric %s not collected", name)
+	return Metric{}
+}
+
+func TestGaugeFuncSampledOnCollect(t *testing.T) {
+	c := newTestCollector(t)
+	var calls float64
+	require.NoError(t, c.RegisterGaugeFunc("goroutines", "Goroutines", func() float64 {
+		calls++
+		return calls
+	}))
+
+	assert.Equal(t, 1.0, findMetric(t, c.Collect(), "goroutines").Value)
+	assert.Equal(t, 2.0, findMetric(t, c.Collect(), "goroutines").Value)
+	assert.Equal(t, 3.0, c.GetGauge("goroutines", nil))
+	assert.Error(t, c.RegisterGaugeFunc("goroutines", "Goroutines", func() float64 { return 0 }))
+}
+
+func TestObserveManyRecordsEveryValue(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("latency_seconds", Histogram, "Latency"))
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	labels := Labels{"route": "/orders"}
+
+	c.ObserveHistogram("latency_seconds", 0.5, labels)
+	c.ObserveMany("latency_seconds", []float64{0.1, 0.2, 0.3}, labels)
+	c.ObserveMany("orders_total", []float64{1, 2}, nil)
+
+	assert.Equal(t, []float64{0.5, 0.1, 0.2, 0.3}, c.GetHistogram("latency_seconds", labels))
+	assert.Equal(t, 0.0, c.GetCounter("orders_total", nil), "non-histograms are ignored")
+}
+
+// latencies are the sub-measurements of one request in the ObserveMany
+// benchmarks
+var latencies = []float64{0.001, 0.004, 0.012, 0.020, 0.031, 0.045}
+
+func BenchmarkObserveMany(b *testing.B) {
+	c := newTestCollector(b)
+	require.NoError(b, c.Register("latency_seconds", Histogram, "Latency"))
+	labels := Labels{"route": "/orders"}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.ObserveMany("latency_seconds", latencies, labels)
+		}
+	})
+}
+
+func BenchmarkObserveHistogramLoop(b *testing.B) {
+	c := newTestCollector(b)
+	require.NoError(b, c.Register("latency_seconds", Histogram, "Latency"))
+	labels := Labels{"route": "/orders"}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for _, v := range latencies {
+				c.ObserveHistogram("latency_seconds", v, labels)
+			}
+		}
+	})
+}
+
+// age backdates the last update of every series of name by d
+func age(c *defaultCollector, name string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, byKey := range c.seriesOf(name) {
+		for _, s := range byKey {
+			atomic.AddInt64(&s.updated, -int64(d))
+		}
+	}
+}
+
+func TestTTLPrunesStaleSeries(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("job_progress", Gauge, "Progress"))
+	require.NoError(t, c.SetTTL("job_progress", time.Minute))
+
+	c.SetGauge("job_progress", 0.5, Labels{"job": "stale"})
+	c.SetGauge("job_progress", 0.2, Labels{"job": "live"})
+	age(c, "job_progress", 2*time.Minute)
+	c.SetGauge("job_progress", 0.9, Labels{"job": "live"})
+
+	series := c.GetSeries("job_progress")
+	require.Len(t, series, 1)
+	assert.Equal(t, Labels{"job": "live"}, series[0].Labels)
+	assert.Equal(t, 0.9, series[0].Value)
+}
+
+func TestTTLStartsForExistingSeries(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("conn_bytes_total", Counter, "Bytes"))
+	c.IncrementCounter("conn_bytes_total", 10, Labels{"conn": "1"})
+	require.NoError(t, c.SetTTL("conn_bytes_total", time.Minute))
+
+	assert.Len(t, c.GetSeries("conn_bytes_total"), 1, "kept until the TTL elapses")
+	age(c, "conn_bytes_total", 2*time.Minute)
+	assert.Empty(t, c.GetSeries("conn_bytes_total"))
+	assert.Error(t, c.SetTTL("unknown", time.Minute))
+}
+
+func TestPruneSkipsLockWithoutTTLs(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("job_progress", Gauge, "Progress"))
+	require.NoError(t, c.SetTTL("job_progress", time.Minute))
+	require.NoError(t, c.SetTTL("job_progress", 0))
+
+	// With the write lock held elsewhere prune only returns if it never
+	// asks for the lock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		c.prune(time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("prune waited for the lock with no TTL set")
+	}
+}
+
+// exportedValue returns the value of the exported Prometheus sample line
+func exportedValue(t *testing.T, c Collector, sample string) float64 {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, c.Export(&buf, FormatPrometheus))
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, sample+" "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			require.NoError(t, err)
+			return v
+		}
+	}
+	t.Fatalf("sample %s not exported", sample)
+	return 0
+}
+
+func TestSampledHistogramScalesCount(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.RegisterAll([]MetricDef{{Name: "latency_seconds", Type: Histogram, SampleRate: 100}}))
+
+	const workers, perWorker = 8, 2500
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				c.ObserveHistogram("latency_seconds", 0.5, nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	const total = workers * perWorker
+	recorded := c.GetHistogram("latency_seconds", nil)
+	assert.Len(t, recorded, total/100, "one in every 100 kept")
+	assert.InEpsilon(t, total, exportedValue(t, c, "latency_seconds_count"), 0.02)
+	assert.InEpsilon(t, total*0.5, exportedValue(t, c, "latency_seconds_sum"), 0.02)
+	assert.Equal(t, 100, findMetric(t, c.Collect(), "latency_seconds").SampleRate)
+}
+
+func TestSampledHistogramScalesEachSeries(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("latency_seconds", Histogram, "Latency", WithSampleRate(2)))
+	h, err := c.NewHistogramHandle("latency_seconds", Labels{"route": "/refunds"})
+	require.NoError(t, err)
+
+	// Interleaved label sets each keep one in every two of their own
+	for i := 0; i < 100; i++ {
+		c.ObserveHistogram("latency_seconds", 0.5, Labels{"route": "/orders"})
+		c.ObserveHistogram("latency_seconds", 0.5, Labels{"route": "/payments"})
+	}
+	for i := 0; i < 100; i++ {
+		c.ObserveHistogram("latency_seconds", 0.5, Labels{"route": "/orders"})
+		h.Observe(0.5)
+	}
+
+	assert.Len(t, c.GetHistogram("latency_seconds", Labels{"route": "/orders"}), 100)
+	assert.Equal(t, 200.0, exportedValue(t, c, `latency_seconds_count{route="/orders"}`))
+	for _, route := range []string{"/payments", "/refunds"} {
+		assert.Len(t, c.GetHistogram("latency_seconds", Labels{"route": route}), 50, route)
+		assert.Equal(t, 100.0, exportedValue(t, c, `latency_seconds_count{route="`+route+`"}`), route)
+	}
+}
+
+func TestRegisterWithSampleRate(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("latency_seconds", Histogram, "Latency", WithSampleRate(10)))
+	require.NoError(t, c.WithPrefix("orders").Register("wait_seconds", Histogram, "Wait", WithSampleRate(10)))
+	h, err := c.NewHistogramHandle("latency_seconds", Labels{"via": "handle"})
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		c.ObserveHistogram("latency_seconds", 0.5, nil)
+		c.ObserveHistogram("orders_wait_seconds", 0.5, nil)
+	}
+	for i := 0; i < 100; i++ {
+		h.Observe(0.5)
+	}
+
+	assert.Len(t, c.GetHistogram("latency_seconds", nil), 10)
+	assert.Len(t, c.GetHistogram("orders_wait_seconds", nil), 10, "options pass through a prefixed view")
+	assert.Len(t, c.GetHistogram("latency_seconds", Labels{"via": "handle"}), 10, "handles sample too")
+	assert.Equal(t, 10, findMetric(t, c.Collect(), "latency_seconds").SampleRate)
+}
+
+func BenchmarkObserveHistogramSampling(b *testing.B) {
+	for _, rate := range []int{1, 100} {
+		b.Run(fmt.Sprintf("1in%d", rate), func(b *testing.B) {
+			c := newTestCollector(b)
+			require.NoError(b, c.Register("latency_seconds", Histogram, "Latency", WithSampleRate(rate)))
+			labels := Labels{"route": "/orders"}
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.ObserveHistogram("latency_seconds", 0.012, labels)
+				}
+			})
+		})
+	}
+}
+
+func TestGetSeriesReturnsEveryLabelSet(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	require.NoError(t, c.Register("refunds_total", Counter, "Refunds"))
+	c.IncrementCounter("orders_total", 1, Labels{"region": "us", "channel": "web"})
+	c.IncrementCounter("orders_total", 2, Labels{"region": "eu", "channel": "web"})
+	c.IncrementCounter("orders_total", 3, Labels{"region": "eu", "channel": "app"})
+	c.IncrementCounter("refunds_total", 1, nil)
+
+	series := c.GetSeries("orders_total")
+
+	require.Len(t, series, 3, "other metrics are excluded")
+	got := make([]Labels, len(series))
+	for i, m := range series {
+		assert.Equal(t, "orders_total", m.Name)
+		assert.Equal(t, Counter, m.Type)
+		assert.Equal(t, "Orders", m.Description)
+		assert.Equal(t, m.Value, c.GetCounter("orders_total", m.Labels))
+		got[i] = m.Labels
+	}
+	assert.Equal(t, []Labels{
+		{"channel": "app", "region": "eu"},
+		{"channel": "web", "region": "eu"},
+		{"channel": "web", "region": "us"},
+	}, got, "ordered by labels")
+}
+
+func TestGetSeriesGauges(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("queue_depth", Gauge, "Depth"))
+	c.SetGauge("queue_depth", 4, Labels{"queue": "payments"})
+	c.SetGauge("queue_depth", 7, Labels{"queue": "emails"})
+	require.NoError(t, c.RegisterGaugeFunc("goroutines", "Goroutines", func() float64 { return 12 }))
+
+	depths := c.GetSeries("queue_depth")
+	require.Len(t, depths, 2)
+	assert.Equal(t, Labels{"queue": "emails"}, depths[0].Labels)
+	assert.Equal(t, 7.0, depths[0].Value)
+	assert.Equal(t, Labels{"queue": "payments"}, depths[1].Labels)
+	assert.Equal(t, 4.0, depths[1].Value)
+
+	funcs := c.GetSeries("goroutines")
+	require.Len(t, funcs, 1)
+	assert.Equal(t, 12.0, funcs[0].Value)
+	assert.Empty(t, c.GetSeries("unknown"))
+}
+
+// populatedCollector returns a collector holding counters, gauges and
+// histograms with series label sets each
+func populatedCollector(t testing.TB, series int) *defaultCollector {
+	t.Helper()
+	c := newTestCollector(t)
+	require.NoError(t, c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter},
+		{Name: "queue_depth", Type: Gauge},
+		{Name: "latency_seconds", Type: Histogram},
+	}))
+	for i := 0; i < series; i++ {
+		labels := Labels{"shard": strconv.Itoa(i)}
+		c.IncrementCounter("orders_total", float64(i), labels)
+		c.SetGauge("queue_depth", float64(i), labels)
+		c.ObserveHistogram("latency_seconds", float64(i)/100, labels)
+	}
+	return c
+}
+
+// withoutTimestamps clears the timestamp of each metric, which differs
+// between collections
+func withoutTimestamps(metrics []Metric) []Metric {
+	out := make([]Metric, len(metrics))
+	for i, m := range metrics {
+		m.Timestamp = time.Time{}
+		out[i] = m
+	}
+	return out
+}
+
+func TestCollectIntoMatchesCollect(t *testing.T) {
+	c := populatedCollector(t, 5)
+	want := withoutTimestamps(c.Collect())
+
+	buf := make([]Metric, 2, 64)
+	buf[0].Name = "stale"
+	got := c.CollectInto(buf)
+
+	assert.ElementsMatch(t, want, withoutTimestamps(got))
+	assert.Same(t, &buf[:1][0], &got[0], "a large enough buffer is reused")
+	assert.ElementsMatch(t, want, withoutTimestamps(c.CollectInto(got)), "reusing the result")
+}
+
+func BenchmarkCollect(b *testing.B) {
+	c := populatedCollector(b, 100)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = c.Collect()
+	}
+}
+
+func BenchmarkCollectInto(b *testing.B) {
+	c := populatedCollector(b, 100)
+	var buf []Metric
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = c.CollectInto(buf)
+	}
+}
+
+// resettingCollector returns a collector in delta mode
+func resettingCollector(t *testing.T) *defaultCollector {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.ResetOnCollect = true
+	c, err := New(cfg)
+	require.NoError(t, err)
+	return c.(*defaultCollector)
+}
+
+// collected returns the values collected for name, in collection order
+func collected(metrics []Metric, name string) []float64 {
+	var values []float64
+	for _, m := range metrics {
+		if m.Name == name {
+			values = append(values, m.Value)
+		}
+	}
+	return values
+}
+
+func TestResetOnCollectStartsEachCollectFresh(t *testing.T) {
+	c := resettingCollector(t)
+	require.NoError(t, c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter},
+		{Name: "queue_depth", Type: Gauge},
+		{Name: "latency_seconds", Type: Histogram},
+	}))
+	c.IncrementCounter("orders_total", 3, nil)
+	c.SetGauge("queue_depth", 7, nil)
+	c.ObserveHistogram("latency_seconds", 0.2, nil)
+	c.ObserveHistogram("latency_seconds", 0.4, nil)
+
+	first := c.Collect()
+	assert.Equal(t, []float64{3}, collected(first, "orders_total"))
+	assert.ElementsMatch(t, []float64{0.2, 0.4}, collected(first, "latency_seconds"))
+
+	second := c.Collect()
+	assert.Equal(t, []float64{0}, collected(second, "orders_total"))
+	assert.Empty(t, collected(second, "latency_seconds"))
+	assert.Equal(t, []float64{7}, collected(second, "queue_depth"), "gauges keep their value")
+
+	c.IncrementCounter("orders_total", 2, nil)
+	c.ObserveHistogram("latency_seconds", 0.1, nil)
+	third := c.Collect()
+	assert.Equal(t, []float64{2}, collected(third, "orders_total"))
+	assert.Equal(t, []float64{0.1}, collected(third, "latency_seconds"))
+}
+
+func TestCollectIsCumulativeByDefault(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.RegisterAll([]MetricDef{
+		{Name: "orders_total", Type: Counter},
+		{Name: "latency_seconds", Type: Histogram},
+	}))
+	c.IncrementCounter("orders_total", 3, nil)
+	c.ObserveHistogram("latency_seconds", 0.2, nil)
+
+	c.Collect()
+	second := c.Collect()
+
+	assert.Equal(t, []float64{3}, collected(second, "orders_total"))
+	assert.Equal(t, []float64{0.2}, collected(second, "latency_seconds"))
+}
+
+func TestResetOnCollectKeepsLabelledSeriesSeparate(t *testing.T) {
+	c := resettingCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	c.IncrementCounter("orders_total", 1, Labels{"region": "eu"})
+	c.IncrementCounter("orders_total", 4, Labels{"region": "us"})
+	c.Collect()
+
+	c.IncrementCounter("orders_total", 2, Labels{"region": "eu"})
+
+	assert.Equal(t, 2.0, c.GetCounter("orders_total", Labels{"region": "eu"}))
+	assert.Equal(t, 0.0, c.GetCounter("orders_total", Labels{"region": "us"}))
+}
+
+func TestResetOnCollectKeepsHandleIncrementsRacingCollect(t *testing.T) {
+	c := resettingCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	h, err := c.NewCounterHandle("orders_total", nil)
+	require.NoError(t, err)
+
+	const total = 5000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < total; i++ {
+			h.Inc()
+		}
+	}()
+
+	var sum float64
+	for collecting := true; collecting; {
+		select {
+		case <-done:
+			collecting = false
+		default:
+		}
+		for _, v := range collected(c.Collect(), "orders_total") {
+			sum += v
+		}
+	}
+	assert.Equal(t, float64(total), sum, "every increment is reported exactly once")
+}
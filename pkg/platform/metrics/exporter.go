@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export implements Collector.Export, writing every registered metric to w in
+// the given exposition format. Histograms are bucketed using the buckets
+// they were registered with, or DefaultBuckets.
+func (c *defaultCollector) Export(w io.Writer, format Format) error {
+	c.prune(time.Now())
+	sampled := c.sampleGaugeFuncs()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.types))
+	for name := range c.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		switch c.types[name] {
+		case Counter:
+			c.writeCounter(bw, name, format)
+		case Gauge:
+			c.writeGauge(bw, name, sampled)
+		case Histogram:
+			c.writeHistogram(bw, name, format)
+		}
+	}
+	if format == FormatOpenMetrics {
+		bw.WriteString("# EOF\n")
+	}
+
+	return bw.Flush()
+}
+
+// writeCounter writes a counter family
+func (c *defaultCollector) writeCounter(w *bufio.Writer, name string, format Format) {
+	family, sample := name, name
+	if format == FormatOpenMetrics {
+		// OpenMetrics counter samples carry the _total suffix, the family doesn't
+		family = strings.TrimSuffix(name, "_total")
+		sample = family + "_total"
+	}
+
+	writeHeader(w, family, "counter", c.descriptions[name])
+	for _, key := range sortedKeys(c.counters[name]) {
+		labels := formatLabels(stringToLabels(key), "", "")
+		fmt.Fprintf(w, "%s%s %s\n", sample, labels, formatFloat(c.counters[name][key].load()))
+		if format == FormatOpenMetrics {
+			fmt.Fprintf(w, "%s_created%s %s\n", family, labels, formatTimestamp(c.created[name]))
+		}
+	}
+}
+
+// writeGauge writes a gauge family, including gauge funcs
+func (c *defaultCollector) writeGauge(w *bufio.Writer, name string, sampled map[string]float64) {
+	writeHeader(w, name, "gauge", c.descriptions[name])
+	if value, ok := sampled[name]; ok {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+		return
+	}
+	for _, key := range sortedKeys(c.gauges[name]) {
+		labels := formatLabels(stringToLabels(key), "", "")
+		fmt.Fprintf(w, "%s%s %s\n", name, labels, formatFloat(c.gauges[name][key].load()))
+	}
+}
+
+// writeHistogram writes a histogram family, attaching exemplars to the
+// bucket they fall into when writing OpenMetrics
+func (c *defaultCollector) writeHistogram(w *bufio.Writer, name string, format Format) {
+	writeHeader(w, name, "histogram", c.descriptions[name])
+
+	for _, key := range sortedKeys(c.histograms[name]) {
+		values := c.histograms[name][key].values
+		labels := stringToLabels(key)
+		exemplar, hasExemplar := c.exemplars[name][key]
+		hasExemplar = hasExemplar && format == FormatOpenMetrics
+
+		// Sampled histograms are scaled back up to estimate the full counts
+		scale := c.sampleRate(name)
+
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		sum *= scale
+
+		buckets := c.buckets[name]
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		bounds := append(append([]float64{}, buckets...), math.Inf(1))
+		exemplarWritten := false
+		for _, bound := range bounds {
+			count := 0
+			for _, v := range values {
+				if v <= bound {
+					count++
+				}
+			}
+			fmt.Fprintf(w, "%s_bucket%s %s", name, formatLabels(labels, "le", formatFloat(bound)), formatFloat(float64(count)*scale))
+			if hasExemplar && !exemplarWritten && exemplar.Value <= bound {
+				fmt.Fprintf(w, " # {trace_id=\"%s\"} %s %s",
+					escapeLabelValue(exemplar.TraceID), formatFloat(exemplar.Value), formatTimestamp(exemplar.Timestamp))
+				exemplarWritten = true
+			}
+			w.WriteByte('\n')
+		}
+
+		formatted := formatLabels(labels, "", "")
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, formatted, formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %s\n", name, formatted, formatFloat(float64(len(values))*scale))
+		if format == FormatOpenMetrics {
+			fmt.Fprintf(w, "%s_created%s %s\n", name, formatted, formatTimestamp(c.created[name]))
+		}
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric family
+func writeHeader(w *bufio.Writer, name, metricType, description string) {
+	if description != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(description))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// formatLabels formats labels as {k="v",...} in key order, with an optional
+// extra label appended last
+func formatLabels(labels Labels, extraKey, extraValue string) string {
+	if len(labels) == 0 && extraKey == "" {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", k, escapeLabelValue(labels[k])))
+	}
+	if extraKey != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extraKey, escapeLabelValue(extraValue)))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// sortedKeys returns the series keys of a metric in order
+func sortedKeys(byKey map[string]*series) []string {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat formats a sample value
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatTimestamp formats a time as fractional Unix seconds
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 3, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+// escapeLabelValue escapes a label value for the text formats
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+// escapeHelp escapes a HELP description for the text formats
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
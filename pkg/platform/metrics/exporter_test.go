@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenTime replaces creation and exemplar times so output is stable
+var goldenTime = time.Unix(1700000000, 500000000)
+
+// exportGolden exports c in format and compares it with testdata/name
+func exportGolden(t *testing.T, c *defaultCollector, format Format, name string) {
+	t.Helper()
+	for metric := range c.created {
+		c.created[metric] = goldenTime
+	}
+	for _, series := range c.exemplars {
+		for key, ex := range series {
+			ex.Timestamp = goldenTime
+			series[key] = ex
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Export(&buf, format))
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+}
+
+// newExportCollector returns a collector with a counter and a histogram
+// holding a few observations
+func newExportCollector(t *testing.T) *defaultCollector {
+	t.Helper()
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders placed"))
+	require.NoError(t, c.RegisterAll([]MetricDef{{
+		Name:        "latency_seconds",
+		Type:        Histogram,
+		Description: "Request latency",
+		Buckets:     []float64{0.1, 0.5, 1},
+	}}))
+	c.IncrementCounter("orders_total", 3, Labels{"region": "eu"})
+	c.ObserveMany("latency_seconds", []float64{0.05, 0.7}, Labels{"route": "/orders"})
+	return c
+}
+
+func TestExportOpenMetrics(t *testing.T) {
+	c := newExportCollector(t)
+	c.ObserveHistogram("latency_seconds", 0.3, Labels{"route": "/orders"})
+	exportGolden(t, c, FormatOpenMetrics, "openmetrics.txt")
+}
+
+func TestExportOpenMetricsWithExemplar(t *testing.T) {
+	c := newExportCollector(t)
+	ctx := context.WithValue(context.Background(), "trace_id", "4bf92f3577b34da6")
+	c.ObserveHistogramContext(ctx, "latency_seconds", 0.3, Labels{"route": "/orders"})
+	exportGolden(t, c, FormatOpenMetrics, "openmetrics_exemplar.txt")
+}
+
+func TestExportPrometheusOmitsExemplar(t *testing.T) {
+	c := newExportCollector(t)
+	ctx := context.WithValue(context.Background(), "trace_id", "4bf92f3577b34da6")
+	c.ObserveHistogramContext(ctx, "latency_seconds", 0.3, Labels{"route": "/orders"})
+	exportGolden(t, c, FormatPrometheus, "prometheus.txt")
+}
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content types served by the metrics handler
+const (
+	contentTypePrometheus  = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	contentTypeJSON        = "application/json"
+)
+
+// handler serves a collector's metrics, optionally behind basic auth
+type handler struct {
+	collector Collector
+	snapshot  func() []Metric // reads the series for JSON responses
+	username  string
+	password  string
+}
+
+// jsonMetric is the JSON representation of a single metric value
+type jsonMetric struct {
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       float64   `json:"value"`
+	Labels      Labels    `json:"labels"`
+	Description string    `json:"description,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	SampleRate  int       `json:"sampleRate,omitempty"`
+}
+
+// Handler implements Collector.Handler. Scrapes never reset series, so in
+// delta mode the handler leaves each interval to the sinks.
+func (c *defaultCollector) Handler() http.Handler {
+	h := newHandler(c, c.username, c.password)
+	h.snapshot = func() []Metric { return c.collect(nil, false) }
+	return h
+}
+
+// newHandler creates a metrics handler; an empty username disables auth
+func newHandler(c Collector, username, password string) *handler {
+	return &handler{collector: c, snapshot: c.Collect, username: username, password: password}
+}
+
+// ServeHTTP implements http.Handler
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := negotiate(r.Header.Get("Accept"))
+	var err error
+	switch contentType {
+	case contentTypeJSON:
+		err = json.NewEncoder(&buf).Encode(toJSONMetrics(h.snapshot()))
+	case contentTypeOpenMetrics:
+		err = h.collector.Export(&buf, FormatOpenMetrics)
+	default:
+		err = h.collector.Export(&buf, FormatPrometheus)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
+
+// authorized checks the request's basic auth credentials
+func (h *handler) authorized(r *http.Request) bool {
+	if h.username == "" {
+		return true
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(h.username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(h.password)) == 1
+	return userOK && passOK
+}
+
+// negotiate picks the content type with the highest quality in accept,
+// preferring earlier entries on ties and Prometheus text by default
+func negotiate(accept string) string {
+	best, bestQ := contentTypePrometheus, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		switch mediaType {
+		case "application/json":
+			best, bestQ = contentTypeJSON, q
+		case "application/openmetrics-text":
+			best, bestQ = contentTypeOpenMetrics, q
+		case "text/plain", "text/*", "*/*":
+			best, bestQ = contentTypePrometheus, q
+		}
+	}
+	return best
+}
+
+// toJSONMetrics converts metrics to their JSON form, sorted by name and
+// labels so responses are stable
+func toJSONMetrics(metrics []Metric) []jsonMetric {
+	sort.SliceStable(metrics, func(i, j int) bool {
+		if metrics[i].Name != metrics[j].Name {
+			return metrics[i].Name < metrics[j].Name
+		}
+		return labelsToString(metrics[i].Labels) < labelsToString(metrics[j].Labels)
+	})
+
+	out := make([]jsonMetric, len(metrics))
+	for i, m := range metrics {
+		labels := m.Labels
+		if labels == nil {
+			labels = Labels{}
+		}
+		out[i] = jsonMetric{
+			Name:        m.Name,
+			Type:        m.Type.String(),
+			Value:       m.Value,
+			Labels:      labels,
+			Description: m.Description,
+			Timestamp:   m.Timestamp,
+			SampleRate:  m.SampleRate,
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+// serveMetrics performs a request against h, adjusted by prepare
+func serveMetrics(h http.Handler, prepare func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if prepare != nil {
+		prepare(req)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerNegotiatesFormat(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	c.IncrementCounter("orders_total", 3, Labels{"region": "eu"})
+
+	tests := []struct {
+		accept      string
+		contentType string
+		contains    string
+	}{
+		{accept: "", contentType: contentTypePrometheus, contains: `orders_total{region="eu"} 3`},
+		{accept: "text/plain", contentType: contentTypePrometheus, contains: "# TYPE orders_total counter"},
+		{accept: "*/*", contentType: contentTypePrometheus, contains: "# TYPE orders_total counter"},
+		{accept: "application/openmetrics-text; version=1.0.0", contentType: contentTypeOpenMetrics, contains: "# EOF"},
+		{accept: "application/json", contentType: contentTypeJSON, contains: `"name":"orders_total"`},
+		{accept: "text/plain;q=0.5, application/json", contentType: contentTypeJSON, contains: `"name":"orders_total"`},
+		{accept: "application/json;q=0.2, text/plain;q=0.9", contentType: contentTypePrometheus, contains: "orders_total"},
+		{accept: "image/png", contentType: contentTypePrometheus, contains: "orders_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			rec := serveMetrics(c.Handler(), func(r *http.Request) { r.Header.Set("Accept", tt.accept) })
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+}
+
+func TestHandlerJSONBody(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	c.IncrementCounter("orders_total", 3, nil)
+
+	rec := serveMetrics(c.Handler(), func(r *http.Request) { r.Header.Set("Accept", "application/json") })
+
+	var got []jsonMetric
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got, 1)
+	assert.Equal(t, "orders_total", got[0].Name)
+	assert.Equal(t, "counter", got[0].Type)
+	assert.Equal(t, 3.0, got[0].Value)
+	assert.Equal(t, Labels{}, got[0].Labels)
+}
+
+func TestHandlerBasicAuth(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Username = "scraper"
+	cfg.Metrics.Password = "s3cret"
+	c, err := New(cfg)
+	require.NoError(t, err)
+	h := c.Handler()
+
+	tests := []struct {
+		name     string
+		prepare  func(*http.Request)
+		wantCode int
+	}{
+		{name: "no credentials", wantCode: http.StatusUnauthorized},
+		{name: "wrong password", prepare: func(r *http.Request) { r.SetBasicAuth("scraper", "nope") }, wantCode: http.StatusUnauthorized},
+		{name: "wrong user", prepare: func(r *http.Request) { r.SetBasicAuth("admin", "s3cret") }, wantCode: http.StatusUnauthorized},
+		{name: "valid", prepare: func(r *http.Request) { r.SetBasicAuth("scraper", "s3cret") }, wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveMetrics(h, tt.prepare)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusUnauthorized {
+				assert.Equal(t, `Basic realm="metrics"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestHandlerWithoutAuthConfigured(t *testing.T) {
+	rec := serveMetrics(newTestCollector(t).Handler(), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
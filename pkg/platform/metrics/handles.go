@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// counterHandle is a CounterHandle bound to one series. It holds the
+// series itself, so updates skip the label key and map lookups.
+type counterHandle struct {
+	c      *defaultCollector
+	name   string
+	key    string
+	series atomic.Pointer[series]
+}
+
+// Inc implements CounterHandle.Inc
+func (h *counterHandle) Inc() {
+	h.Add(1)
+}
+
+// Add implements CounterHandle.Add
+func (h *counterHandle) Add(value float64) {
+	if value < 0 {
+		// Negative deltas are counted and rejected or clamped under the lock
+		h.c.addCounter(h.name, h.key, value)
+		return
+	}
+	s := h.c.live(&h.series, h.c.counters, h.name, h.key)
+	s.add(value)
+	h.c.touch(s)
+}
+
+// gaugeHandle is a GaugeHandle bound to one series
+type gaugeHandle struct {
+	c      *defaultCollector
+	name   string
+	key    string
+	series atomic.Pointer[series]
+}
+
+// Set implements GaugeHandle.Set
+func (h *gaugeHandle) Set(value float64) {
+	s := h.c.live(&h.series, h.c.gauges, h.name, h.key)
+	s.store(value)
+	h.c.touch(s)
+}
+
+// Add implements GaugeHandle.Add
+func (h *gaugeHandle) Add(delta float64) {
+	s := h.c.live(&h.series, h.c.gauges, h.name, h.key)
+	s.add(delta)
+	h.c.touch(s)
+}
+
+// histogramHandle is a HistogramHandle bound to one series. Observations
+// still take the lock, but skip the label key, map and sampler lookups.
+type histogramHandle struct {
+	c       *defaultCollector
+	name    string
+	key     string
+	sampler *sampler // nil when every observation is recorded
+	sampled *uint64  // the series' observation count for sampler
+	series  *series  // guarded by c.mu
+}
+
+// Observe implements HistogramHandle.Observe
+func (h *histogramHandle) Observe(value float64) {
+	if h.sampler != nil && !h.sampler.keep(h.sampled) {
+		return
+	}
+
+	h.c.mu.Lock()
+	defer h.c.mu.Unlock()
+
+	if atomic.LoadInt32(&h.series.pruned) == 1 {
+		h.series = h.c.seriesFor(h.c.histograms, h.name, h.key)
+	}
+	h.series.values = append(h.series.values, value)
+	h.c.touch(h.series)
+}
+
+// live returns the series held in p, resolving it again once prune has
+// removed it
+func (c *defaultCollector) live(p *atomic.Pointer[series], byName map[string]map[string]*series, name, key string) *series {
+	if s := p.Load(); atomic.LoadInt32(&s.pruned) == 0 {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.seriesFor(byName, name, key)
+	p.Store(s)
+	return s
+}
+
+// resolve returns the series of name identified by labels in byName,
+// creating it if needed
+func (c *defaultCollector) resolve(byName map[string]map[string]*series, name string, labels Labels) (*series, error) {
+	if err := validateLabels(labels); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seriesFor(byName, name, labelsToString(labels)), nil
+}
+
+// NewCounterHandle implements Collector.NewCounterHandle
+func (c *defaultCollector) NewCounterHandle(name string, labels Labels) (CounterHandle, error) {
+	if err := c.checkType(name, Counter); err != nil {
+		return nil, err
+	}
+	s, err := c.resolve(c.counters, name, labels)
+	if err != nil {
+		return nil, err
+	}
+	h := &counterHandle{c: c, name: name, key: labelsToString(labels)}
+	h.series.Store(s)
+	return h, nil
+}
+
+// NewGaugeHandle implements Collector.NewGaugeHandle
+func (c *defaultCollector) NewGaugeHandle(name string, labels Labels) (GaugeHandle, error) {
+	if err := c.checkType(name, Gauge); err != nil {
+		return nil, err
+	}
+	s, err := c.resolve(c.gauges, name, labels)
+	if err != nil {
+		return nil, err
+	}
+	h := &gaugeHandle{c: c, name: name, key: labelsToString(labels)}
+	h.series.Store(s)
+	return h, nil
+}
+
+// NewHistogramHandle implements Collector.NewHistogramHandle
+func (c *defaultCollector) NewHistogramHandle(name string, labels Labels) (HistogramHandle, error) {
+	if err := c.checkType(name, Histogram); err != nil {
+		return nil, err
+	}
+	s, err := c.resolve(c.histograms, name, labels)
+	if err != nil {
+		return nil, err
+	}
+	h := &histogramHandle{c: c, name: name, key: labelsToString(labels), series: s}
+	if v, ok := c.samplers.Load(name); ok {
+		h.sampler = v.(*sampler)
+		h.sampled = h.sampler.counter(h.key)
+	}
+	return h, nil
+}
+
+// checkType returns an error unless name is registered with the given type
+func (c *defaultCollector) checkType(name string, metricType MetricType) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	actual, exists := c.types[name]
+	if !exists {
+		return fmt.Errorf("metric %s not registered", name)
+	}
+	if actual != metricType {
+		return fmt.Errorf("metric %s is not a %s", name, metricType)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCollector returns an enabled collector with default settings
+func newTestCollector(t testing.TB) *defaultCollector {
+	t.Helper()
+	return newCollectorWithMode(t, "")
+}
+
+func TestCounterHandleSharesSeriesWithStringCalls(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	labels := Labels{"region": "eu"}
+
+	h, err := c.NewCounterHandle("orders_total", labels)
+	require.NoError(t, err)
+	h.Inc()
+	h.Add(2)
+	c.IncrementCounter("orders_total", 4, Labels{"region": "eu"})
+
+	assert.Equal(t, 7.0, c.GetCounter("orders_total", labels))
+	assert.Len(t, c.GetSeries("orders_total"), 1)
+}
+
+func TestGaugeAndHistogramHandlesShareSeriesWithStringCalls(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("queue_depth", Gauge, "Depth"))
+	require.NoError(t, c.Register("latency_seconds", Histogram, "Latency"))
+
+	g, err := c.NewGaugeHandle("queue_depth", nil)
+	require.NoError(t, err)
+	g.Set(3)
+	c.SetGauge("queue_depth", c.GetGauge("queue_depth", nil)+1, nil)
+	g.Add(1)
+	assert.Equal(t, 5.0, c.GetGauge("queue_depth", nil))
+
+	h, err := c.NewHistogramHandle("latency_seconds", Labels{"op": "read"})
+	require.NoError(t, err)
+	h.Observe(0.1)
+	c.ObserveHistogram("latency_seconds", 0.2, Labels{"op": "read"})
+	assert.Equal(t, []float64{0.1, 0.2}, c.GetHistogram("latency_seconds", Labels{"op": "read"}))
+}
+
+func TestNewHandleRejectsUnknownOrMistypedMetric(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("queue_depth", Gauge, "Depth"))
+
+	_, err := c.NewCounterHandle("orders_totl", nil)
+	assert.ErrorContains(t, err, "not registered")
+	_, err = c.NewCounterHandle("queue_depth", nil)
+	assert.ErrorContains(t, err, "is not a")
+	_, err = c.NewGaugeHandle("queue_depth", Labels{"bad-label": "x"})
+	assert.Error(t, err)
+}
+
+func TestCounterHandleConcurrentIncrements(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	h, err := c.NewCounterHandle("orders_total", nil)
+	require.NoError(t, err)
+
+	const workers, perWorker = 8, 1000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				h.Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, float64(workers*perWorker), c.GetCounter("orders_total", nil))
+}
+
+func TestHandlesRecreatePrunedSeries(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	require.NoError(t, c.Register("latency_seconds", Histogram, "Latency"))
+	require.NoError(t, c.SetTTL("orders_total", time.Minute))
+	require.NoError(t, c.SetTTL("latency_seconds", time.Minute))
+	counter, err := c.NewCounterHandle("orders_total", nil)
+	require.NoError(t, err)
+	histogram, err := c.NewHistogramHandle("latency_seconds", nil)
+	require.NoError(t, err)
+	counter.Add(2)
+	histogram.Observe(0.1)
+
+	age(c, "orders_total", 2*time.Minute)
+	age(c, "latency_seconds", 2*time.Minute)
+	require.Empty(t, c.GetSeries("orders_total"))
+
+	counter.Inc()
+	histogram.Observe(0.2)
+	assert.Equal(t, 1.0, c.GetCounter("orders_total", nil))
+	assert.Equal(t, []float64{0.2}, c.GetHistogram("latency_seconds", nil))
+}
+
+func BenchmarkCounterIncrement(b *testing.B) {
+	labels := Labels{"region": "eu", "status": "ok"}
+
+	b.Run("string", func(b *testing.B) {
+		c := newTestCollector(b)
+		require.NoError(b, c.Register("orders_total", Counter, "Orders"))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.IncrementCounter("orders_total", 1, labels)
+		}
+	})
+	b.Run("handle", func(b *testing.B) {
+		c := newTestCollector(b)
+		require.NoError(b, c.Register("orders_total", Counter, "Orders"))
+		h, err := c.NewCounterHandle("orders_total", labels)
+		require.NoError(b, err)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			h.Inc()
+		}
+	})
+}
+
+func BenchmarkHistogramObserve(b *testing.B) {
+	labels := Labels{"route": "/orders"}
+
+	b.Run("string", func(b *testing.B) {
+		c := newTestCollector(b)
+		require.NoError(b, c.Register("latency_seconds", Histogram, "Latency"))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.ObserveHistogram("latency_seconds", 0.1, labels)
+		}
+	})
+	b.Run("handle", func(b *testing.B) {
+		c := newTestCollector(b)
+		require.NoError(b, c.Register("latency_seconds", Histogram, "Latency"))
+		h, err := c.NewHistogramHandle("latency_seconds", labels)
+		require.NoError(b, err)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			h.Observe(0.1)
+		}
+	})
+}
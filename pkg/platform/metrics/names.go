@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validName reports whether name matches [a-zA-Z_][a-zA-Z0-9_]*
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !validNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// validNameByte reports whether b may appear in a name, digits being
+// allowed everywhere but the first position
+func validNameByte(b byte, first bool) bool {
+	switch {
+	case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b == '_':
+		return true
+	case b >= '0' && b <= '9':
+		return !first
+	default:
+		return false
+	}
+}
+
+// validateName returns an error unless name is a valid metric name
+func validateName(name string) error {
+	if !validName(name) {
+		return fmt.Errorf("invalid metric name %q: must match [a-zA-Z_][a-zA-Z0-9_]*", name)
+	}
+	return nil
+}
+
+// validateLabels returns an error for the first invalid label name
+func validateLabels(labels Labels) error {
+	for key := range labels {
+		if !validName(key) {
+			return fmt.Errorf("invalid label name %q: must match [a-zA-Z_][a-zA-Z0-9_]*", key)
+		}
+	}
+	return nil
+}
+
+// SanitizeName turns s into a valid metric or label name by replacing
+// invalid characters with underscores and prefixing a leading digit
+func SanitizeName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	var sb strings.Builder
+	if s[0] >= '0' && s[0] <= '9' {
+		sb.WriteByte('_')
+	}
+	for i := 0; i < len(s); i++ {
+		if validNameByte(s[i], false) {
+			sb.WriteByte(s[i])
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// checkLabels reports whether labels are valid for an update of name,
+// counting the dropped update otherwise
+func (c *defaultCollector) checkLabels(name string, labels Labels) bool {
+	if validateLabels(labels) == nil {
+		return true
+	}
+	c.mu.Lock()
+	c.countInvalid(InvalidLabelsMetric, name)
+	c.mu.Unlock()
+	return false
+}
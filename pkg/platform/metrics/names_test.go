@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterValidatesName(t *testing.T) {
+	c := newTestCollector(t)
+
+	assert.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	for _, name := range []string{"orders-total", "orders total", "1orders", ""} {
+		err := c.Register(name, Counter, "")
+		assert.ErrorContains(t, err, "invalid metric name", "%q", name)
+	}
+	assert.ErrorContains(t, c.RegisterAll([]MetricDef{{Name: "orders-total", Type: Counter}}), "orders-total")
+}
+
+func TestBadLabelKeyRejected(t *testing.T) {
+	c := newTestCollector(t)
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	bad := Labels{"payment-method": "card"}
+
+	c.IncrementCounter("orders_total", 1, bad)
+	_, err := c.NewCounterHandle("orders_total", bad)
+
+	assert.ErrorContains(t, err, `invalid label name "payment-method"`)
+	assert.Empty(t, c.GetSeries("orders_total"), "the update was dropped")
+	assert.Equal(t, 1.0, c.GetCounter(InvalidLabelsMetric, Labels{"metric": "orders_total"}))
+
+	c.IncrementCounter("orders_total", 1, Labels{"payment_method": "card"})
+	assert.Equal(t, 1.0, c.GetCounter("orders_total", Labels{"payment_method": "card"}))
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"orders_total":     "orders_total",
+		"orders-total":     "orders_total",
+		"orders total":     "orders_total",
+		"http.latency.p99": "http_latency_p99",
+		"5xx_responses":    "_5xx_responses",
+		"":                 "_",
+	}
+	for in, want := range tests {
+		got := SanitizeName(in)
+		assert.Equal(t, want, got, "%q", in)
+		assert.True(t, validName(got), "%q", got)
+	}
+}
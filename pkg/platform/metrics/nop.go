@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// nopCollector is a Collector that records nothing
+type nopCollector struct{}
+
+// nopHandle is a handle that records nothing
+type nopHandle struct{}
+
+func (nopHandle) Inc()            {}
+func (nopHandle) Add(float64)     {}
+func (nopHandle) Set(float64)     {}
+func (nopHandle) Observe(float64) {}
+
+// NewNop returns a Collector that records nothing and whose getters return
+// zero values
+func NewNop() Collector {
+	return nopCollector{}
+}
+
+func (nopCollector) IncrementCounter(name string, value float64, labels Labels) {}
+func (nopCollector) GetCounter(name string, labels Labels) float64              { return 0 }
+func (nopCollector) SetGauge(name string, value float64, labels Labels)         {}
+func (nopCollector) GetGauge(name string, labels Labels) float64                { return 0 }
+func (nopCollector) RegisterGaugeFunc(name string, description string, fn func() float64) error {
+	return nil
+}
+func (nopCollector) ObserveHistogram(name string, value float64, labels Labels) {}
+func (nopCollector) ObserveMany(name string, values []float64, labels Labels)   {}
+func (nopCollector) ObserveHistogramContext(ctx context.Context, name string, value float64, labels Labels) {
+}
+func (nopCollector) GetHistogram(name string, labels Labels) []float64 { return nil }
+func (nopCollector) NewCounterHandle(name string, labels Labels) (CounterHandle, error) {
+	return nopHandle{}, nil
+}
+func (nopCollector) NewGaugeHandle(name string, labels Labels) (GaugeHandle, error) {
+	return nopHandle{}, nil
+}
+func (nopCollector) NewHistogramHandle(name string, labels Labels) (HistogramHandle, error) {
+	return nopHandle{}, nil
+}
+func (nopCollector) Register(name string, metricType MetricType, description string, opts ...RegisterOption) error {
+	return nil
+}
+func (nopCollector) RegisterAll(defs []MetricDef) error          { return nil }
+func (nopCollector) SetTTL(name string, ttl time.Duration) error { return nil }
+func (nopCollector) Collect() []Metric                           { return nil }
+func (nopCollector) CollectInto(buf []Metric) []Metric           { return buf[:0] }
+func (nopCollector) GetSeries(name string) []Metric              { return nil }
+func (nopCollector) Export(w io.Writer, format Format) error     { return nil }
+func (n nopCollector) Handler() http.Handler                     { return newHandler(n, "", "") }
+func (n nopCollector) WithPrefix(prefix string) Collector        { return n }
+func (nopCollector) RegisterSink(sink func([]Metric)) func()     { return func() {} }
+func (nopCollector) RunSinks(ctx context.Context)                {}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ Collector       = nopCollector{}
+	_ CounterHandle   = nopHandle{}
+	_ GaugeHandle     = nopHandle{}
+	_ HistogramHandle = nopHandle{}
+)
+
+func TestNopCollectorRecordsNothing(t *testing.T) {
+	c := NewNop()
+	labels := Labels{"region": "eu"}
+
+	assert.NotPanics(t, func() {
+		require.NoError(t, c.Register("orders_total", Counter, ""))
+		require.NoError(t, c.RegisterAll([]MetricDef{{Name: "orders_total", Type: Counter}}))
+		require.NoError(t, c.RegisterGaugeFunc("queue_depth", "", nil))
+		require.NoError(t, c.SetTTL("orders_total", 0))
+
+		c.IncrementCounter("orders_total", 1, labels)
+		c.SetGauge("queue_depth", 3, nil)
+		c.ObserveHistogram("latency_seconds", 0.2, nil)
+		c.ObserveMany("latency_seconds", []float64{0.1, 0.3}, nil)
+		c.ObserveHistogramContext(context.Background(), "latency_seconds", 0.2, nil)
+
+		counter, err := c.NewCounterHandle("orders_total", labels)
+		require.NoError(t, err)
+		counter.Inc()
+		counter.Add(2)
+		gauge, err := c.NewGaugeHandle("queue_depth", nil)
+		require.NoError(t, err)
+		gauge.Set(1)
+		gauge.Add(-1)
+		histogram, err := c.NewHistogramHandle("latency_seconds", nil)
+		require.NoError(t, err)
+		histogram.Observe(0.5)
+
+		unregister := c.RegisterSink(func([]Metric) {})
+		unregister()
+		c.RunSinks(context.Background())
+	})
+
+	assert.Zero(t, c.GetCounter("orders_total", labels))
+	assert.Zero(t, c.GetGauge("queue_depth", nil))
+	assert.Empty(t, c.GetHistogram("latency_seconds", nil))
+	assert.Empty(t, c.GetSeries("orders_total"))
+	assert.Empty(t, c.Collect())
+	assert.Empty(t, c.CollectInto(make([]Metric, 3)))
+	assert.Equal(t, c, c.WithPrefix("orders"))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Export(&buf, FormatPrometheus))
+	assert.Empty(t, buf.String())
+}
+
+func TestNopCollectorHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	NewNop().Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
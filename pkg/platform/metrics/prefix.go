@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// prefixedCollector is a view of a collector that namespaces metric names.
+// Collect, CollectInto, Export, Handler and the sink operations are
+// promoted from the embedded collector: storage is shared, so they cover
+// every namespace.
+type prefixedCollector struct {
+	Collector
+	prefix string
+}
+
+// WithPrefix implements Collector.WithPrefix
+func (c *defaultCollector) WithPrefix(prefix string) Collector {
+	return &prefixedCollector{Collector: c, prefix: prefix + "_"}
+}
+
+// WithPrefix implements Collector.WithPrefix, nesting the new prefix
+// inside this one
+func (p *prefixedCollector) WithPrefix(prefix string) Collector {
+	return &prefixedCollector{Collector: p.Collector, prefix: p.prefix + prefix + "_"}
+}
+
+// name returns the namespaced metric name
+func (p *prefixedCollector) name(name string) string {
+	return p.prefix + name
+}
+
+// IncrementCounter implements Collector.IncrementCounter
+func (p *prefixedCollector) IncrementCounter(name string, value float64, labels Labels) {
+	p.Collector.IncrementCounter(p.name(name), value, labels)
+}
+
+// GetCounter implements Collector.GetCounter
+func (p *prefixedCollector) GetCounter(name string, labels Labels) float64 {
+	return p.Collector.GetCounter(p.name(name), labels)
+}
+
+// SetGauge implements Collector.SetGauge
+func (p *prefixedCollector) SetGauge(name string, value float64, labels Labels) {
+	p.Collector.SetGauge(p.name(name), value, labels)
+}
+
+// GetGauge implements Collector.GetGauge
+func (p *prefixedCollector) GetGauge(name string, labels Labels) float64 {
+	return p.Collector.GetGauge(p.name(name), labels)
+}
+
+// RegisterGaugeFunc implements Collector.RegisterGaugeFunc
+func (p *prefixedCollector) RegisterGaugeFunc(name string, description string, fn func() float64) error {
+	return p.Collector.RegisterGaugeFunc(p.name(name), description, fn)
+}
+
+// ObserveHistogram implements Collector.ObserveHistogram
+func (p *prefixedCollector) ObserveHistogram(name string, value float64, labels Labels) {
+	p.Collector.ObserveHistogram(p.name(name), value, labels)
+}
+
+// ObserveMany implements Collector.ObserveMany
+func (p *prefixedCollector) ObserveMany(name string, values []float64, labels Labels) {
+	p.Collector.ObserveMany(p.name(name), values, labels)
+}
+
+// ObserveHistogramContext implements Collector.ObserveHistogramContext
+func (p *prefixedCollector) ObserveHistogramContext(ctx context.Context, name string, value float64, labels Labels) {
+	p.Collector.ObserveHistogramContext(ctx, p.name(name), value, labels)
+}
+
+// GetSeries implements Collector.GetSeries
+func (p *prefixedCollector) GetSeries(name string) []Metric {
+	return p.Collector.GetSeries(p.name(name))
+}
+
+// GetHistogram implements Collector.GetHistogram
+func (p *prefixedCollector) GetHistogram(name string, labels Labels) []float64 {
+	return p.Collector.GetHistogram(p.name(name), labels)
+}
+
+// NewCounterHandle implements Collector.NewCounterHandle
+func (p *prefixedCollector) NewCounterHandle(name string, labels Labels) (CounterHandle, error) {
+	return p.Collector.NewCounterHandle(p.name(name), labels)
+}
+
+// NewGaugeHandle implements Collector.NewGaugeHandle
+func (p *prefixedCollector) NewGaugeHandle(name string, labels Labels) (GaugeHandle, error) {
+	return p.Collector.NewGaugeHandle(p.name(name), labels)
+}
+
+// NewHistogramHandle implements Collector.NewHistogramHandle
+func (p *prefixedCollector) NewHistogramHandle(name string, labels Labels) (HistogramHandle, error) {
+	return p.Collector.NewHistogramHandle(p.name(name), labels)
+}
+
+// Register implements Collector.Register
+func (p *prefixedCollector) Register(name string, metricType MetricType, description string, opts ...RegisterOption) error {
+	return p.Collector.Register(p.name(name), metricType, description, opts...)
+}
+
+// RegisterAll implements Collector.RegisterAll
+func (p *prefixedCollector) RegisterAll(defs []MetricDef) error {
+	prefixed := make([]MetricDef, len(defs))
+	for i, def := range defs {
+		def.Name = p.name(def.Name)
+		prefixed[i] = def
+	}
+	return p.Collector.RegisterAll(prefixed)
+}
+
+// SetTTL implements Collector.SetTTL
+func (p *prefixedCollector) SetTTL(name string, ttl time.Duration) error {
+	return p.Collector.SetTTL(p.name(name), ttl)
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPrefixNamespacesRegisterAndIncrement(t *testing.T) {
+	c := newTestCollector(t)
+	orders := c.WithPrefix("orders")
+
+	require.NoError(t, orders.Register("placed_total", Counter, "Orders placed"))
+	orders.IncrementCounter("placed_total", 2, Labels{"region": "eu"})
+
+	m := findMetric(t, c.Collect(), "orders_placed_total")
+	assert.Equal(t, 2.0, m.Value)
+	assert.Equal(t, Labels{"region": "eu"}, m.Labels)
+	assert.Equal(t, 2.0, orders.GetCounter("placed_total", Labels{"region": "eu"}))
+	assert.Equal(t, 2.0, c.GetCounter("orders_placed_total", Labels{"region": "eu"}))
+}
+
+func TestWithPrefixNestsAndSharesStorage(t *testing.T) {
+	c := newTestCollector(t)
+	payments := c.WithPrefix("orders").WithPrefix("payments")
+
+	require.NoError(t, payments.RegisterAll([]MetricDef{{Name: "latency_seconds", Type: Histogram}}))
+	payments.ObserveHistogram("latency_seconds", 0.2, nil)
+
+	assert.Equal(t, []float64{0.2}, c.GetHistogram("orders_payments_latency_seconds", nil))
+	assert.Len(t, payments.Collect(), len(c.Collect()), "Collect covers every namespace")
+	assert.Error(t, c.Register("orders_payments_latency_seconds", Histogram, ""), "the prefixed name is taken")
+}
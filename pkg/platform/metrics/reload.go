@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"time"
+
+	"order-system/pkg/infra/config"
+)
+
+// Config reload metric names
+const (
+	ConfigReloadMetric     = "config_reload_total"
+	ConfigLastReloadMetric = "config_last_reload_timestamp"
+)
+
+// ObserveConfigReloads records every reload attempt of p on c: the
+// config_reload_total counter labelled result="success" or "failure", and
+// the config_last_reload_timestamp gauge in Unix seconds
+func ObserveConfigReloads(p *config.Provider, c Collector) error {
+	if err := c.Register(ConfigReloadMetric, Counter, "Configuration reload attempts by result"); err != nil {
+		return err
+	}
+	if err := c.Register(ConfigLastReloadMetric, Gauge, "Unix time of the last configuration reload attempt"); err != nil {
+		return err
+	}
+
+	p.OnReload(func(err error) {
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		c.IncrementCounter(ConfigReloadMetric, 1, Labels{"result": result})
+		c.SetGauge(ConfigLastReloadMetric, float64(time.Now().UnixNano())/1e9, nil)
+	})
+	return nil
+}
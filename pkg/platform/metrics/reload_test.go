@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order-system/pkg/infra/config"
+)
+
+// reloadCollector is a Collector that records counter increments and
+// gauge updates
+type reloadCollector struct {
+	Collector
+	mu       sync.Mutex
+	counters map[string]float64
+	gauges   map[string]float64
+}
+
+func newReloadCollector() *reloadCollector {
+	return &reloadCollector{
+		Collector: NewNop(),
+		counters:  make(map[string]float64),
+		gauges:    make(map[string]float64),
+	}
+}
+
+func (c *reloadCollector) IncrementCounter(name string, value float64, labels Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counters[name+"{result="+labels["result"]+"}"] += value
+}
+
+func (c *reloadCollector) SetGauge(name string, value float64, labels Labels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gauges[name] = value
+}
+
+// counter returns the recorded total of name with the given result label
+func (c *reloadCollector) counter(name, result string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counters[name+"{result="+result+"}"]
+}
+
+// gauge returns the last value set on name
+func (c *reloadCollector) gauge(name string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gauges[name]
+}
+
+// writeReloadConfig writes a valid config with the given log level to path
+func writeReloadConfig(t *testing.T, path, level string) {
+	t.Helper()
+	doc := map[string]interface{}{
+		"database": map[string]interface{}{
+			"host": "localhost", "port": 3306, "user": "app", "database": "orders",
+			"maxOpenConns": 10, "maxIdleConns": 5, "maxLifetime": int64(time.Hour),
+		},
+		"http": map[string]interface{}{
+			"port": 8080, "readTimeout": int64(time.Second), "writeTimeout": int64(time.Second),
+		},
+		"logger": map[string]interface{}{"level": level},
+	}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestObserveConfigReloadsRecordsOutcomes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeReloadConfig(t, path, "info")
+	p := config.NewProvider(path)
+	require.NoError(t, p.Load())
+
+	c := newReloadCollector()
+	require.NoError(t, ObserveConfigReloads(p, c))
+
+	before := float64(time.Now().Unix())
+	writeReloadConfig(t, path, "debug")
+	require.NoError(t, p.Reload())
+	assert.Equal(t, 1.0, c.counter(ConfigReloadMetric, "success"))
+	assert.Zero(t, c.counter(ConfigReloadMetric, "failure"))
+	first := c.gauge(ConfigLastReloadMetric)
+	assert.GreaterOrEqual(t, first, before)
+
+	writeReloadConfig(t, path, "loud")
+	require.Error(t, p.Reload())
+	assert.Equal(t, 1.0, c.counter(ConfigReloadMetric, "success"))
+	assert.Equal(t, 1.0, c.counter(ConfigReloadMetric, "failure"))
+	assert.GreaterOrEqual(t, c.gauge(ConfigLastReloadMetric), first)
+	assert.Equal(t, "debug", p.Get().Logger.Level)
+}
+
+func TestObserveConfigReloadsRegistersMetrics(t *testing.T) {
+	c := newTestCollector(t)
+
+	require.NoError(t, ObserveConfigReloads(config.NewProvider("unused.json"), c))
+
+	assert.Equal(t, Counter, c.types[ConfigReloadMetric])
+	assert.Equal(t, Gauge, c.types[ConfigLastReloadMetric])
+	assert.Error(t, ObserveConfigReloads(config.NewProvider("unused.json"), c), "metrics already registered")
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// RegisterSink implements Collector.RegisterSink. The returned function
+// removes the sink; both are safe to call while RunSinks is running.
+func (c *defaultCollector) RegisterSink(sink func([]Metric)) func() {
+	c.sinkMu.Lock()
+	defer c.sinkMu.Unlock()
+
+	id := c.nextSinkID
+	c.nextSinkID++
+	c.sinks[id] = sink
+
+	return func() {
+		c.sinkMu.Lock()
+		defer c.sinkMu.Unlock()
+		delete(c.sinks, id)
+	}
+}
+
+// RunSinks implements Collector.RunSinks. Every metrics interval it takes a
+// single Collect snapshot and passes it to each registered sink, until ctx
+// is cancelled. Sinks share the snapshot and must not modify it.
+func (c *defaultCollector) RunSinks(ctx context.Context) {
+	interval := c.interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.flushSinks()
+		}
+	}
+}
+
+// flushSinks sends one snapshot to every registered sink
+func (c *defaultCollector) flushSinks() {
+	c.sinkMu.Lock()
+	sinks := make([]func([]Metric), 0, len(c.sinks))
+	for _, sink := range c.sinks {
+		sinks = append(sinks, sink)
+	}
+	c.sinkMu.Unlock()
+
+	if len(sinks) == 0 {
+		return
+	}
+
+	snapshot := c.Collect()
+	for _, sink := range sinks {
+		sink(snapshot)
+	}
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextSnapshot waits for a snapshot sent to ch
+func nextSnapshot(t *testing.T, ch <-chan []Metric) []Metric {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(time.Second):
+		t.Fatal("no snapshot received")
+		return nil
+	}
+}
+
+func TestSinksShareOneSnapshotPerTick(t *testing.T) {
+	c := newTestCollector(t)
+	c.interval = 5 * time.Millisecond
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	c.IncrementCounter("orders_total", 1, nil)
+
+	push, statsd := make(chan []Metric, 1), make(chan []Metric, 1)
+	c.RegisterSink(func(m []Metric) { push <- m })
+	removeStatsd := c.RegisterSink(func(m []Metric) { statsd <- m })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.RunSinks(ctx)
+
+	for tick := 0; tick < 3; tick++ {
+		a, b := nextSnapshot(t, push), nextSnapshot(t, statsd)
+		require.NotEmpty(t, a)
+		assert.Same(t, &a[0], &b[0], "tick %d: both sinks get the same snapshot", tick)
+	}
+
+	removeStatsd()
+	nextSnapshot(t, push)
+	nextSnapshot(t, push)
+	select {
+	case <-statsd:
+		// At most one snapshot can have been in flight during removal
+	default:
+	}
+	nextSnapshot(t, push)
+	select {
+	case <-statsd:
+		t.Fatal("removed sink still receives snapshots")
+	default:
+	}
+}
+
+func TestResetOnCollectLeavesIntervalToSinksAfterScrapes(t *testing.T) {
+	c := resettingCollector(t)
+	c.interval = 5 * time.Millisecond
+	require.NoError(t, c.Register("orders_total", Counter, "Orders"))
+	c.IncrementCounter("orders_total", 3, nil)
+
+	for _, accept := range []string{"application/json", "text/plain", "application/openmetrics-text"} {
+		rec := serveMetrics(c.Handler(), func(r *http.Request) { r.Header.Set("Accept", accept) })
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "orders_total", accept)
+	}
+
+	pushed := make(chan []Metric, 1)
+	c.RegisterSink(func(m []Metric) { pushed <- m })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.RunSinks(ctx)
+
+	assert.Equal(t, []float64{3}, collected(nextSnapshot(t, pushed), "orders_total"), "scrapes did not take the delta")
+	assert.Equal(t, []float64{0}, collected(nextSnapshot(t, pushed), "orders_total"), "the sink push reset the series")
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"sort"
+)
+
+// Percentile returns the q-quantile (0 <= q <= 1) of values using linear
+// interpolation between the closest ranks. The input is not modified and
+// empty input returns 0.
+func Percentile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return percentileSorted(sortedCopy(values), q)
+}
+
+// Summary returns the min, max, mean and the 50th, 90th and 99th
+// percentiles of values. The input is not modified and empty input returns
+// zeros.
+func Summary(values []float64) (min, max, mean, p50, p90, p99 float64) {
+	if len(values) == 0 {
+		return 0, 0, 0, 0, 0, 0
+	}
+
+	sorted := sortedCopy(values)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	return sorted[0],
+		sorted[len(sorted)-1],
+		sum / float64(len(sorted)),
+		percentileSorted(sorted, 0.5),
+		percentileSorted(sorted, 0.9),
+		percentileSorted(sorted, 0.99)
+}
+
+// sortedCopy returns a sorted copy of values
+func sortedCopy(values []float64) []float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// percentileSorted computes a percentile over already sorted values
+func percentileSorted(sorted []float64, q float64) float64 {
+	if q <= 0 {
+		return sorted[0]
+	}
+	if q >= 1 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := q * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
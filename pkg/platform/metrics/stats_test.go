@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dataset is unsorted so the tests also catch sorting the caller's slice;
+// the expected values follow linear interpolation between closest ranks
+var dataset = []float64{50, 15, 40, 20, 35}
+
+func TestPercentileKnownDataset(t *testing.T) {
+	input := append([]float64(nil), dataset...)
+	for q, want := range map[float64]float64{
+		0:    15,
+		0.4:  29,
+		0.5:  35,
+		0.9:  46,
+		0.99: 49.6,
+		1:    50,
+	} {
+		assert.InDelta(t, want, Percentile(input, q), 1e-9, "q=%v", q)
+	}
+	assert.Equal(t, dataset, input, "input order is preserved")
+}
+
+func TestSummaryKnownDataset(t *testing.T) {
+	min, max, mean, p50, p90, p99 := Summary(dataset)
+
+	assert.Equal(t, 15.0, min)
+	assert.Equal(t, 50.0, max)
+	assert.InDelta(t, 32.0, mean, 1e-9)
+	assert.InDelta(t, 35.0, p50, 1e-9)
+	assert.InDelta(t, 46.0, p90, 1e-9)
+	assert.InDelta(t, 49.6, p99, 1e-9)
+}
+
+func TestStatsEmptyInput(t *testing.T) {
+	assert.Zero(t, Percentile(nil, 0.5))
+	min, max, mean, p50, p90, p99 := Summary([]float64{})
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, 0}, []float64{min, max, mean, p50, p90, p99})
+}
+
+func TestPercentileSingleValue(t *testing.T) {
+	assert.Equal(t, 7.0, Percentile([]float64{7}, 0.9))
+}
@@ -1,6 +1,11 @@
 package metrics
 
-import "time"
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
 
 // MetricType represents the type of metric
 type MetricType int
@@ -14,6 +19,88 @@ const (
 	Histogram
 )
 
+// NegativeCounterMode controls how negative counter increments are handled
+type NegativeCounterMode int
+
+const (
+	// RejectNegative discards negative increments
+	RejectNegative NegativeCounterMode = iota
+	// ClampNegative applies negative increments as zero
+	ClampNegative
+)
+
+// InvalidCounterMetric counts negative increments, labelled by metric name
+const InvalidCounterMetric = "counter_invalid_total"
+
+// InvalidLabelsMetric counts updates dropped for invalid label names,
+// labelled by metric name
+const InvalidLabelsMetric = "metric_invalid_labels_total"
+
+// String returns the string representation of the metric type
+func (t MetricType) String() string {
+	switch t {
+	case Counter:
+		return "counter"
+	case Gauge:
+		return "gauge"
+	case Histogram:
+		return "histogram"
+	default:
+		return "unknown"
+	}
+}
+
+// Format represents a metrics exposition format
+type Format int
+
+const (
+	// FormatPrometheus is the Prometheus text exposition format
+	FormatPrometheus Format = iota
+	// FormatOpenMetrics is the OpenMetrics text format, which adds _created
+	// series, exemplars and a terminating # EOF
+	FormatOpenMetrics
+)
+
+// DefaultBuckets are the histogram bucket upper bounds used by the exporter
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Exemplar links a histogram observation to the trace that produced it
+type Exemplar struct {
+	TraceID   string
+	Value     float64
+	Timestamp time.Time
+}
+
+// MetricDef describes a metric for batch registration
+type MetricDef struct {
+	Name        string
+	Type        MetricType
+	Description string
+	// Buckets are the histogram bucket upper bounds used when exporting;
+	// DefaultBuckets is used when empty
+	Buckets []float64
+	// SampleRate records only one in every SampleRate observations of
+	// each histogram series to cut lock traffic on very hot histograms.
+	// Export scales counts and sums back up, so they are estimates whose
+	// error grows as the rate rises or traffic falls. Collect, CollectInto,
+	// the JSON handler and sinks report only the recorded samples, each with
+	// Metric.SampleRate set so consumers can scale them; GetHistogram
+	// returns the recorded samples unscaled. Zero or one records
+	// everything.
+	SampleRate int
+}
+
+// RegisterOption configures a metric registered with Register
+type RegisterOption func(*MetricDef)
+
+// WithSampleRate records only one in every rate observations of a
+// histogram, as MetricDef.SampleRate does
+func WithSampleRate(rate int) RegisterOption {
+	return func(def *MetricDef) {
+		def.SampleRate = rate
+	}
+}
+
 // Labels represents metric labels
 type Labels map[string]string
 
@@ -25,6 +112,28 @@ type Metric struct {
 	Labels      Labels
 	Description string
 	Timestamp   time.Time
+	// SampleRate is set on observations of a sampled histogram to the
+	// number of observations each recorded one stands for; it is zero for
+	// everything else
+	SampleRate int
+}
+
+// CounterHandle updates a single counter series. The series is resolved
+// once at creation, so updates skip label validation, keying and lookups.
+type CounterHandle interface {
+	Inc()
+	Add(value float64)
+}
+
+// GaugeHandle updates a single gauge series, like CounterHandle
+type GaugeHandle interface {
+	Set(value float64)
+	Add(delta float64)
+}
+
+// HistogramHandle updates a single histogram series, like CounterHandle
+type HistogramHandle interface {
+	Observe(value float64)
 }
 
 // Collector defines the metrics collection interface
@@ -36,12 +145,39 @@ type Collector interface {
 	// Gauge operations
 	SetGauge(name string, value float64, labels Labels)
 	GetGauge(name string, labels Labels) float64
+	RegisterGaugeFunc(name string, description string, fn func() float64) error
 
 	// Histogram operations
 	ObserveHistogram(name string, value float64, labels Labels)
+	ObserveMany(name string, values []float64, labels Labels)
+	ObserveHistogramContext(ctx context.Context, name string, value float64, labels Labels)
 	GetHistogram(name string, labels Labels) []float64
 
+	// Handle operations
+	NewCounterHandle(name string, labels Labels) (CounterHandle, error)
+	NewGaugeHandle(name string, labels Labels) (GaugeHandle, error)
+	NewHistogramHandle(name string, labels Labels) (HistogramHandle, error)
+
 	// General operations
-	Register(name string, metricType MetricType, description string) error
+	Register(name string, metricType MetricType, description string, opts ...RegisterOption) error
+	RegisterAll(defs []MetricDef) error
+	SetTTL(name string, ttl time.Duration) error
 	Collect() []Metric
+	// CollectInto is Collect appending to buf[:0], so callers can reuse
+	// one buffer across scrapes
+	CollectInto(buf []Metric) []Metric
+	// GetSeries returns every series of the named counter or gauge,
+	// ordered by labels
+	GetSeries(name string) []Metric
+	Export(w io.Writer, format Format) error
+	// Handler serves the metrics over HTTP in the format negotiated from
+	// the Accept header
+	Handler() http.Handler
+	// WithPrefix returns a view that prepends prefix + "_" to every metric
+	// name while sharing the underlying storage
+	WithPrefix(prefix string) Collector
+
+	// Sink operations
+	RegisterSink(sink func([]Metric)) func()
+	RunSinks(ctx context.Context)
 }
@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// nopTracer is a Tracer that does nothing
+type nopTracer struct{}
+
+// StartSpan implements Tracer.StartSpan
+func (nopTracer) StartSpan(ctx context.Context, name string) (context.Context, FinishFunc) {
+	return ctx, func(error) {}
+}
+
+// Nop returns a Tracer that does nothing
+func Nop() Tracer {
+	return nopTracer{}
+}
+
+// recorder is a Tracer that hands each finished span to a callback
+type recorder struct {
+	record func(Span)
+}
+
+// NewRecorder creates a Tracer that measures each span's duration and status
+// and passes the finished span to record
+func NewRecorder(record func(Span)) Tracer {
+	return &recorder{record: record}
+}
+
+// StartSpan implements Tracer.StartSpan
+func (r *recorder) StartSpan(ctx context.Context, name string) (context.Context, FinishFunc) {
+	start := time.Now()
+	return ctx, func(err error) {
+		r.record(Span{
+			Name:     name,
+			Start:    start,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+}
+
+// tracerHolder wraps the global tracer so atomic.Value sees a single type
+type tracerHolder struct {
+	tracer Tracer
+}
+
+var global atomic.Value
+
+func init() {
+	global.Store(tracerHolder{tracer: Nop()})
+}
+
+// SetTracer sets the global tracer used by the HTTP client and database
+// layer. Passing nil restores the no-op tracer.
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = Nop()
+	}
+	global.Store(tracerHolder{tracer: t})
+}
+
+// GetTracer returns the global tracer
+func GetTracer() Tracer {
+	return global.Load().(tracerHolder).tracer
+}
+
+// StartSpan starts a span using the global tracer
+func StartSpan(ctx context.Context, name string) (context.Context, FinishFunc) {
+	return GetTracer().StartSpan(ctx, name)
+}
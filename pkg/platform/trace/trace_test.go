@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderReportsNameAndStatus(t *testing.T) {
+	var spans []Span
+	tracer := NewRecorder(func(s Span) { spans = append(spans, s) })
+
+	_, finish := tracer.StartSpan(context.Background(), "http.GET")
+	finish(nil)
+	_, finish = tracer.StartSpan(context.Background(), "db.query")
+	finish(errors.New("boom"))
+
+	require.Len(t, spans, 2)
+	assert.Equal(t, "http.GET", spans[0].Name)
+	assert.True(t, spans[0].OK())
+	assert.Equal(t, "db.query", spans[1].Name)
+	assert.False(t, spans[1].OK())
+	assert.EqualError(t, spans[1].Err, "boom")
+	assert.GreaterOrEqual(t, spans[1].Duration.Nanoseconds(), int64(0))
+}
+
+func TestSetTracerRoutesGlobalSpans(t *testing.T) {
+	t.Cleanup(func() { SetTracer(nil) })
+	var names []string
+	SetTracer(NewRecorder(func(s Span) { names = append(names, s.Name) }))
+
+	_, finish := StartSpan(context.Background(), "db.exec")
+	finish(nil)
+	assert.Equal(t, []string{"db.exec"}, names)
+
+	SetTracer(nil)
+	_, finish = StartSpan(context.Background(), "db.exec")
+	finish(nil)
+	assert.Equal(t, []string{"db.exec"}, names, "nil restores the no-op tracer")
+}
@@ -0,0 +1,29 @@
+package trace
+
+import (
+	"context"
+	"time"
+)
+
+// FinishFunc ends a span, recording err as its status
+type FinishFunc func(err error)
+
+// Tracer starts spans around operations
+type Tracer interface {
+	// StartSpan starts a span and returns the derived context and a function
+	// that must be called to finish it
+	StartSpan(ctx context.Context, name string) (context.Context, FinishFunc)
+}
+
+// Span represents a finished span
+type Span struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// OK returns true if the span finished without an error
+func (s Span) OK() bool {
+	return s.Err == nil
+}